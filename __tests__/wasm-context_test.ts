@@ -23,3 +23,15 @@ test('ArtBoard: extracts MediaBox of artboard', async () => {
   const artboard = await ArtBoard(ctx, ref)
   expect(artboard.MediaBox).toStrictEqual([0.0, 0.0, 177.0, 175.0])
 })
+
+test('privateData: reads private data repeatedly', async () => {
+  const ctx = await testCtx()
+  const read = async (): Promise<string[]> => {
+    const lines: string[] = []
+    for await (const line of ctx.privateData()) lines.push(Buffer.from(line).toString('latin1'))
+    return lines
+  }
+  const first = await read()
+  expect(first.length).toBeGreaterThan(0)
+  expect(await read()).toStrictEqual(first)
+})
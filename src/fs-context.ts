@@ -55,6 +55,7 @@ export async function FSContext(opts: DumpSerializedOpts): Promise<FsContext> {
   return {
     aiFile,
     privateData: () => lineReader(PrivateData),
+    privateDataAbsent: aiFile.PrivateDataAbsent,
     streamDict: (num: number) => readFile(path.join(BaseDir, StreamDicts[num])),
     externalResourceURLs,
     xobjectMutex: new Map(),
@@ -34,7 +34,9 @@ export function ArtBoardRefs(ctx: Context): ArtBoardRef[] {
 
 export function PrivateData(ctx: Context): ReturnType<typeof parsePrivateData> {
   if (!ctx.parsedPrivateData) {
-    ctx.parsedPrivateData = parsePrivateData(() => ctx.privateData())
+    ctx.parsedPrivateData = ctx.privateDataAbsent
+      ? Promise.resolve({ LayerNames: [] })
+      : parsePrivateData(() => ctx.privateData())
   }
   return ctx.parsedPrivateData
 }
@@ -14,6 +14,8 @@ export interface Context {
   aiFile: AIFile
   streamDict: StreamDictFetcher
   privateData: RawPrivateData
+  // reason why private data is missing, i.e. file is a plain PDF - privateData yields nothing then
+  privateDataAbsent?: string
 
   // Images & fonts are references to external resources.
   // e.g. in FSContext as files on disk
@@ -18,13 +18,35 @@ export interface ParsedFile {
   value: string // JSON-serialized
   bitmaps: Record<number, BitmapReader>
   fonts: Record<number, FontReader>
-  // privateData: () => Promise<{ done: boolean, value: Uint8Array }> // almost AsyncIterator, dunno how to create one from Go WASM
-  privateData: AsyncIterator<Uint8Array>
+  // returns a new iterator on each call, so private data can be read repeatedly
+  privateData: () => AsyncIterator<Uint8Array>
+  privateDataAbsent?: string // set for plain PDFs, privateData is empty then
   streamDict: StreamDictFetcher
+  operators: (objId: number) => Promise<string> // JSON-serialized, see wasm/contents
+}
+
+// see wasm/errors.go
+export type AICpuErrorCode =
+  | 'NOT_ILLUSTRATOR'
+  | 'LEGACY_FORMAT'
+  | 'MISSING_PIECE_INFO'
+  | 'TRUNCATED_PRIVATE_DATA'
+  | 'UNSUPPORTED_COMPRESSION'
+  | 'VALIDATION'
+  | 'IMAGE_DECODE'
+  | 'LIMIT_EXCEEDED'
+  | 'CANCELED'
+  | 'UNKNOWN'
+
+// all promises returned by Go code are rejected with AICpuError
+export interface AICpuError extends Error {
+  code: AICpuErrorCode
+  objNr?: number
 }
 
 export interface AICpu {
-  parse: (fileBytes: Uint8Array) => Promise<ParsedFile>
+  // aborting signal rejects pending and future reads of parsed file as well
+  parse: (fileBytes: Uint8Array, signal?: AbortSignal) => Promise<ParsedFile>
   exit: () => void
   bufferSize: number
 }
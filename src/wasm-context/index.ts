@@ -4,7 +4,7 @@ import type { Go } from './go'
 import type { WasmContext } from './interfaces'
 import { Proxy } from './proxy'
 
-export type { BitmapReader, FontReader, Bitmap, Font } from './go'
+export type { BitmapReader, FontReader, Bitmap, Font, AICpuError, AICpuErrorCode } from './go'
 export type { WasmContext } from './interfaces'
 
 async function instantiate(go: Go): Promise<WebAssembly.WebAssemblyInstantiatedSource> {
@@ -21,7 +21,9 @@ async function instantiate(go: Go): Promise<WebAssembly.WebAssemblyInstantiatedS
 const ONE_GIGABYTE = 1024 * 1024 * 1024
 
 export interface WASMContextOptions {
+  // size of private data chunks read at once, longer lines are split and glued back together
   bufferSize?: number
+  signal?: AbortSignal
 }
 export async function WASMContext(data: Uint8Array, options: WASMContextOptions = {}): Promise<WasmContext> {
   if (data.length > ONE_GIGABYTE) {
@@ -44,6 +46,6 @@ export async function WASMContext(data: Uint8Array, options: WASMContextOptions
       + "you need to exit() previous instance of WASMContext before allocating larger buffer'
     )
 
-  const parsed = await aicpu.parse(data)
+  const parsed = await aicpu.parse(data, options.signal)
   return new Proxy(aicpu, parsed)
 }
@@ -14,36 +14,38 @@ export class Proxy implements WasmContext {
   public readonly xobjectMutex: Map<number, Promise<unknown[]>> = new Map()
   public readonly fontCache: Map<number, Promise<Font>> = new Map()
   public readonly parsedPrivateData?: Promise<PrivateData>
+  public readonly privateDataAbsent?: string
   public readonly strictPopplerCompat = true
 
-  private _privateDataCalled = false
-
   constructor(private readonly aicpu: AICpu, private readonly parsed: ParsedFile) {
     this.aiFile = JSON.parse(this.parsed.value) as AIFile
 
     this.streamDict = this.parsed.streamDict
+    this.privateDataAbsent = this.parsed.privateDataAbsent
 
     this.Bitmaps = this.parsed.bitmaps
     this.Fonts = this.parsed.fonts
   }
 
   public async *privateData(): AsyncGenerator<Uint8Array> {
-    if (this._privateDataCalled) throw new Error('AssertionError: WASMContext.privateData called twice')
-    this._privateDataCalled = true
+    const iterator = this.parsed.privateData()
+    // long lines may be split across chunks, their unterminated parts are collected and joined once the line ends
+    let pending: Uint8Array[] = []
     for (;;) {
-      const { value, done } = await this.parsed.privateData.next()
-      let line = value
+      const { value, done } = await iterator.next()
+      let chunk: Uint8Array = value ?? new Uint8Array(0)
       for (;;) {
-        while (line[0] === LINE_FEED) line = line.subarray(1)
-        while (line[0] === CARRIAGE_RETURN) line = line.subarray(1)
-
-        const offset = line.indexOf(CARRIAGE_RETURN)
-        yield line.subarray(0, offset)
+        if (pending.length === 0) chunk = skipLineBreaks(chunk)
+        const offset = chunk.indexOf(CARRIAGE_RETURN)
         if (offset === -1) break
-        line = line.subarray(offset + 1)
+        yield join(pending, chunk.subarray(0, offset))
+        pending = []
+        chunk = chunk.subarray(offset + 1)
       }
+      if (chunk.length > 0) pending.push(chunk)
       if (done) break
     }
+    if (pending.length > 0) yield join(pending, new Uint8Array(0))
   }
 
   public exit() {
@@ -51,3 +53,22 @@ export class Proxy implements WasmContext {
     globalThis.IllustratorParser = undefined
   }
 }
+
+// skipLineBreaks drops empty lines, \n of \r\n included
+function skipLineBreaks(chunk: Uint8Array): Uint8Array {
+  let start = 0
+  while (chunk[start] === LINE_FEED || chunk[start] === CARRIAGE_RETURN) start++
+  return chunk.subarray(start)
+}
+
+// join copies parts of a line once, lines fitting a single chunk are returned as they are
+function join(parts: Uint8Array[], last: Uint8Array): Uint8Array {
+  if (parts.length === 0) return last
+  const ret = new Uint8Array(parts.reduce((length, part) => length + part.length, last.length))
+  let offset = 0
+  for (const part of [...parts, last]) {
+    ret.set(part, offset)
+    offset += part.length
+  }
+  return ret
+}
@@ -0,0 +1,265 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"math"
+
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// ArtboardSetup is an artboard as recorded in private data, coordinates are Illustrator ones
+type ArtboardSetup struct {
+	Name string
+	// Rect spans PositionPoint1 (top left corner) and PositionPoint2 (bottom right corner)
+	Rect        *pdfcpu.Rectangle
+	RulerOrigin []float64
+	// PAR is pixel aspect ratio
+	PAR      float64
+	Selected bool
+	// Order is position in the artboards panel
+	Order int
+	// Guides holds guides crossing the artboard
+	Guides []Guide
+}
+
+type GuideOrientation string
+
+const (
+	GuideHorizontal GuideOrientation = "horizontal"
+	GuideVertical   GuideOrientation = "vertical"
+	GuideAngled     GuideOrientation = "angled"
+)
+
+// Guide is a straight path turned into guide, Line holds its end points as x1 y1 x2 y2
+type Guide struct {
+	Orientation GuideOrientation
+	Line        [4]float64
+}
+
+// ReadArtboardSetups returns artboards of private data in order of the artboards panel. Those are dictionaries of
+// `%_` serialized objects (`/Dictionary :` ... `; ,`) with `(Name)`, `(PositionPoint1)`, `(PositionPoint2)`,
+// `(RulerOrigin)`, `(PAR)` and `(IsArtboardSelected)` entries. Guides are two-point paths painted by `*`. It consumes pd.
+func ReadArtboardSetups(ctx context.Context, pd PrivateData) ([]*ArtboardSetup, error) {
+	var setups []*ArtboardSetup
+	var guides []Guide
+	sr := serializedReader{onClose: func(entries serializedEntries) {
+		if setup := entries.artboardSetup(); setup != nil {
+			setup.Order = len(setups)
+			setups = append(setups, setup)
+		}
+	}}
+	// path holds points of the path being read, guides are painted right after the second one
+	var path []float64
+	continuation := false
+	for pd.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		line := pd.Bytes()
+		lineStart := !continuation
+		continuation = pd.Continued()
+		if !lineStart || len(line) == 0 {
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("%_")) {
+			sr.parseLine(line[len("%_"):])
+			continue
+		}
+		if line[0] == '%' {
+			continue
+		}
+		switch line[len(line)-1] {
+		case 'm', 'L', 'l', '*':
+		default:
+			path = nil
+			continue
+		}
+		parser := contents.NewParser(line)
+		for parser.Scan() {
+			op := parser.Operator()
+			switch op.Name {
+			case "m":
+				path = nil
+				fallthrough
+			case "L", "l":
+				if point, err := numbers(op.Args); err == nil && len(point) == 2 {
+					path = append(path, point...)
+				}
+			case "*":
+				if len(path) == 4 {
+					guides = append(guides, newGuide(path))
+				}
+				path = nil
+			default:
+				path = nil
+			}
+		}
+	}
+	if err := pd.Err(); err != nil {
+		return nil, errors.WithMessage(err, "while reading artboards")
+	}
+	for _, setup := range setups {
+		for _, guide := range guides {
+			if guide.crosses(setup.Rect) {
+				setup.Guides = append(setup.Guides, guide)
+			}
+		}
+	}
+	return setups, nil
+}
+
+func newGuide(path []float64) Guide {
+	guide := Guide{Orientation: GuideAngled}
+	copy(guide.Line[:], path)
+	switch {
+	case path[1] == path[3]:
+		guide.Orientation = GuideHorizontal
+	case path[0] == path[2]:
+		guide.Orientation = GuideVertical
+	}
+	return guide
+}
+
+// crosses tells whether bounding box of the guide line overlaps rect
+func (guide Guide) crosses(rect *pdfcpu.Rectangle) bool {
+	line := guide.Line
+	return math.Max(line[0], line[2]) >= rect.LL.X && math.Min(line[0], line[2]) <= rect.UR.X &&
+		math.Max(line[1], line[3]) >= rect.LL.Y && math.Min(line[1], line[3]) <= rect.UR.Y
+}
+
+// serializedEntries maps keys of a serialized dictionary to values preceding their type, e.g. `612 792 /RealPoint (RulerOrigin) ,`
+type serializedEntries map[string][]contents.Operand
+
+// serializedReader follows nesting of `%_` serialized objects, which open by `/Type :` and close by `;` followed by
+// their key within the enclosing dictionary, if any
+type serializedReader struct {
+	stack   []serializedEntries
+	onClose func(entries serializedEntries)
+	// pending holds values of an entry continued on the next line
+	pending []byte
+}
+
+// parseLine handles a line without the %_ prefix, lines which are not content stream syntax are skipped
+func (sr *serializedReader) parseLine(line []byte) {
+	sr.pending = append(append(sr.pending, line...), ' ')
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 || bytes.IndexByte([]byte(":;,"), trimmed[len(trimmed)-1]) < 0 {
+		return
+	}
+	parser := contents.NewParser(sr.pending)
+	// operands refer to pending, so it is not reused
+	defer func() { sr.pending = nil }()
+	for parser.Scan() {
+		op := parser.Operator()
+		switch op.Name {
+		case ":":
+			sr.stack = append(sr.stack, serializedEntries{})
+		case ";":
+			if len(sr.stack) > 0 {
+				sr.onClose(sr.stack[len(sr.stack)-1])
+				sr.stack = sr.stack[:len(sr.stack)-1]
+			}
+		case ",":
+			if len(sr.stack) == 0 || len(op.Args) < 2 {
+				continue
+			}
+			key, ok := op.Args[len(op.Args)-1].(contents.LiteralString)
+			if !ok {
+				continue
+			}
+			sr.stack[len(sr.stack)-1][string(key.Unescape())] = op.Args[:len(op.Args)-2]
+		}
+	}
+}
+
+func (entries serializedEntries) numbers(key string) []float64 {
+	values, err := numbers(entries[key])
+	if err != nil {
+		return nil
+	}
+	return values
+}
+
+// artboardSetup returns nil unless entries describe an artboard
+func (entries serializedEntries) artboardSetup() *ArtboardSetup {
+	topLeft, bottomRight := entries.numbers("PositionPoint1"), entries.numbers("PositionPoint2")
+	if len(topLeft) != 2 || len(bottomRight) != 2 {
+		return nil
+	}
+	setup := ArtboardSetup{
+		Name:        firstString(entries["Name"]),
+		Rect:        pdfcpu.Rect(topLeft[0], bottomRight[1], bottomRight[0], topLeft[1]),
+		RulerOrigin: entries.numbers("RulerOrigin"),
+		PAR:         1,
+	}
+	if par := entries.numbers("PAR"); len(par) == 1 {
+		setup.PAR = par[0]
+	}
+	if selected := entries.numbers("IsArtboardSelected"); len(selected) == 1 {
+		setup.Selected = selected[0] != 0
+	}
+	return &setup
+}
+
+// matchArtboardSetups assigns setups to artboards in two passes. Illustrator coordinates of artboards placed in one
+// PDF coordinate space differ from MediaBoxes by a translation, the one agreed on by most pairs of the same size is
+// taken - a page is given the setup whose rect, once translated, is its MediaBox, so that pages in different order
+// than artboards are matched right. Pages exported with origins of their own, e.g. all at [0 0 w h], agree on no
+// translation, those take the first free setup of the same size in order of setups. Pages of other sizes than all
+// free setups are left without one.
+func matchArtboardSetups(artboards []Artboard, setups []*ArtboardSetup) {
+	type offset struct{ x, y int64 }
+	sameSize := func(box, rect *pdfcpu.Rectangle) bool {
+		return math.Abs(box.Width()-rect.Width()) <= 0.5 && math.Abs(box.Height()-rect.Height()) <= 0.5
+	}
+	votes := map[offset]int{}
+	var best offset
+	for idx := range artboards {
+		box := artboards[idx].MediaBox
+		if box == nil {
+			continue
+		}
+		for _, setup := range setups {
+			if !sameSize(box, setup.Rect) {
+				continue
+			}
+			o := offset{int64(math.Round(box.LL.X - setup.Rect.LL.X)), int64(math.Round(box.LL.Y - setup.Rect.LL.Y))}
+			votes[o]++
+			if votes[o] > votes[best] {
+				best = o
+			}
+		}
+	}
+
+	used := make([]bool, len(setups))
+	assign := func(accept func(box, rect *pdfcpu.Rectangle) bool) {
+		for idx := range artboards {
+			box := artboards[idx].MediaBox
+			if box == nil || artboards[idx].Setup != nil {
+				continue
+			}
+			for sIdx, setup := range setups {
+				if used[sIdx] || !accept(box, setup.Rect) {
+					continue
+				}
+				used[sIdx] = true
+				artboards[idx].Setup = setup
+				if setup.Name != "" {
+					artboards[idx].Name = setup.Name
+				}
+				break
+			}
+		}
+	}
+	if votes[best] > 0 {
+		assign(func(box, rect *pdfcpu.Rectangle) bool {
+			dx, dy := float64(best.x), float64(best.y)
+			return math.Abs(rect.LL.X+dx-box.LL.X) <= 0.5 && math.Abs(rect.LL.Y+dy-box.LL.Y) <= 0.5 &&
+				math.Abs(rect.UR.X+dx-box.UR.X) <= 0.5 && math.Abs(rect.UR.Y+dy-box.UR.Y) <= 0.5
+		})
+	}
+	assign(sameSize)
+}
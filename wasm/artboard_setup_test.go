@@ -0,0 +1,127 @@
+package wasm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+func TestReadArtboardSetups(t *testing.T) {
+	pd := testPrivateData(
+		"%_/ArtboardArray :",
+		"%_/Dictionary :",
+		`%_(A\(1\)) /UnicodeString (Name) ,`,
+		"%_0 /Bool (IsArtboardSelected) ,",
+		"%_0 0 /RealPoint",
+		"%_ (RulerOrigin) ,",
+		"%_0 0 /RealPoint (PositionPoint1) ,",
+		"%_100 -100 /RealPoint (PositionPoint2) ,",
+		"%_1 /Real (PAR) ,",
+		"%_; ,",
+		"%_/Dictionary :",
+		"%_(B) /UnicodeString (Name) ,",
+		"%_1 /Bool (IsArtboardSelected) ,",
+		"%_200 0 /RealPoint (RulerOrigin) ,",
+		"%_200 0 /RealPoint (PositionPoint1) ,",
+		"%_350 -50 /RealPoint (PositionPoint2) ,",
+		"%_; ,",
+		"%_; (ArtboardArray) ,",
+		"-50 -20 m",
+		"400 -20 L",
+		"(N) *",
+		"250 10 m",
+		"250 -200 L",
+		"0 0 m",
+		"10 10 L",
+	)
+	setups, err := ReadArtboardSetups(context.Background(), pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guide := Guide{GuideHorizontal, [4]float64{-50, -20, 400, -20}}
+	expected := []*ArtboardSetup{
+		{Name: "A(1)", Rect: pdfcpu.Rect(0, -100, 100, 0), RulerOrigin: []float64{0, 0}, PAR: 1, Guides: []Guide{guide}},
+		{Name: "B", Rect: pdfcpu.Rect(200, -50, 350, 0), RulerOrigin: []float64{200, 0}, PAR: 1, Selected: true, Order: 1, Guides: []Guide{guide}},
+	}
+	if !reflect.DeepEqual(setups, expected) {
+		t.Errorf("got %+v, expected %+v", setups, expected)
+	}
+}
+
+func TestMatchArtboardSetups(t *testing.T) {
+	setupA := &ArtboardSetup{Name: "A", Rect: pdfcpu.Rect(0, -100, 100, 0)}
+	setupB := &ArtboardSetup{Name: "B", Rect: pdfcpu.Rect(200, -50, 350, 0), Order: 1}
+	setupC := &ArtboardSetup{Name: "C", Rect: pdfcpu.Rect(400, -100, 500, 0), Order: 2}
+	setupD := &ArtboardSetup{Name: "D", Rect: pdfcpu.Rect(200, -100, 300, 0), Order: 1}
+	tests := []struct {
+		name       string
+		mediaBoxes []*pdfcpu.Rectangle
+		setups     []*ArtboardSetup
+		expected   []*ArtboardSetup
+	}{
+		{
+			name:       "same order",
+			mediaBoxes: []*pdfcpu.Rectangle{pdfcpu.Rect(0, 0, 100, 100), pdfcpu.Rect(200, 50, 350, 100)},
+			setups:     []*ArtboardSetup{setupA, setupB},
+			expected:   []*ArtboardSetup{setupA, setupB},
+		},
+		{
+			name: "same size in different order",
+			mediaBoxes: []*pdfcpu.Rectangle{
+				pdfcpu.Rect(410, 100, 510, 200), pdfcpu.Rect(10, 100, 110, 200), pdfcpu.Rect(210, 150, 360, 200),
+			},
+			setups:   []*ArtboardSetup{setupA, setupB, setupC},
+			expected: []*ArtboardSetup{setupC, setupA, setupB},
+		},
+		{
+			name: "page without setup",
+			mediaBoxes: []*pdfcpu.Rectangle{
+				pdfcpu.Rect(10, 100, 110, 200), pdfcpu.Rect(700, 100, 800, 200), pdfcpu.Rect(410, 100, 510, 200),
+			},
+			setups:   []*ArtboardSetup{setupA, setupB, setupC},
+			expected: []*ArtboardSetup{setupA, nil, setupC},
+		},
+		{
+			name: "pages sharing one origin",
+			mediaBoxes: []*pdfcpu.Rectangle{
+				pdfcpu.Rect(0, 0, 100, 100), pdfcpu.Rect(0, 0, 150, 50), pdfcpu.Rect(0, 0, 100, 100),
+			},
+			setups:   []*ArtboardSetup{setupA, setupB, setupC},
+			expected: []*ArtboardSetup{setupA, setupB, setupC},
+		},
+		{
+			name:       "pages sharing one origin, artboards apart",
+			mediaBoxes: []*pdfcpu.Rectangle{pdfcpu.Rect(0, 0, 100, 100), pdfcpu.Rect(0, 0, 100, 100)},
+			setups:     []*ArtboardSetup{setupA, setupD},
+			expected:   []*ArtboardSetup{setupA, setupD},
+		},
+		{
+			name:       "no setups",
+			mediaBoxes: []*pdfcpu.Rectangle{pdfcpu.Rect(0, 0, 100, 100)},
+			expected:   []*ArtboardSetup{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			artboards := make([]Artboard, len(tt.mediaBoxes))
+			for idx, box := range tt.mediaBoxes {
+				artboards[idx] = Artboard{Idx: idx, Name: "default", MediaBox: box}
+			}
+			matchArtboardSetups(artboards, tt.setups)
+			for idx, artboard := range artboards {
+				if artboard.Setup != tt.expected[idx] {
+					t.Errorf("artboard %d got setup %+v, expected %+v", idx, artboard.Setup, tt.expected[idx])
+				}
+				name := "default"
+				if tt.expected[idx] != nil {
+					name = tt.expected[idx].Name
+				}
+				if artboard.Name != name {
+					t.Errorf("artboard %d got name %q, expected %q", idx, artboard.Name, name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// Artboard is a leaf of the Pages tree, together with attributes inherited from its ancestors
+type Artboard struct {
+	Idx       int
+	Ref       pdfcpu.IndirectRef
+	Name      string
+	MediaBox  *pdfcpu.Rectangle
+	CropBox   *pdfcpu.Rectangle
+	ArtBox    *pdfcpu.Rectangle
+	BleedBox  *pdfcpu.Rectangle
+	Rotate    int
+	Resources pdfcpu.Dict
+	// Contents holds object numbers of content streams, to be used with IllustratorFile.Scene
+	Contents []int
+	// Setup is the artboard of private data matched to the page, nil if there is none
+	Setup *ArtboardSetup
+}
+
+// %_(Mesa de trabajo 1) /UnicodeString (Name) ,
+// ->
+// Mesa de trabajo 1
+var artboardNameRe = regexp.MustCompile(`^%_\((.+)\) /UnicodeString \(Name\) ,`)
+
+type inheritedAttrs struct {
+	mediaBox, cropBox pdfcpu.Object
+	resources         pdfcpu.Object
+	rotate            pdfcpu.Object
+}
+
+func (attrs inheritedAttrs) update(dict pdfcpu.Dict) inheritedAttrs {
+	if obj, ok := dict.Find("MediaBox"); ok {
+		attrs.mediaBox = obj
+	}
+	if obj, ok := dict.Find("CropBox"); ok {
+		attrs.cropBox = obj
+	}
+	if obj, ok := dict.Find("Resources"); ok {
+		attrs.resources = obj
+	}
+	if obj, ok := dict.Find("Rotate"); ok {
+		attrs.rotate = obj
+	}
+	return attrs
+}
+
+// Artboards walks the Pages tree and returns its leafs in document order.
+// Setups and names are matched from private data if it was requested, see IllustratorFile.IndexedPrivateData.
+// Legacy files have a single artboard, see LegacyInfo.ArtboardBox.
+func (f *IllustratorFile) Artboards(ctx context.Context) ([]Artboard, error) {
+	if f.Legacy != nil {
+		return f.Legacy.artboards(), nil
+	}
+	if f.SerializedFile == nil {
+		return nil, errors.New("artboards require serialized file")
+	}
+	setups, err := f.readArtboardSetups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	xRefTable := &f.SerializedFile.XRefTable
+	root, err := xRefTable.Pages()
+	if err != nil {
+		return nil, errors.WithMessage(err, "while looking for Pages")
+	}
+
+	var artboards []Artboard
+	var walk func(ref pdfcpu.IndirectRef, attrs inheritedAttrs) error
+	walk = func(ref pdfcpu.IndirectRef, attrs inheritedAttrs) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		node, err := xRefTable.DereferenceDict(ref)
+		if err != nil {
+			return errors.WithMessagef(err, "page node %s", ref)
+		}
+		attrs = attrs.update(node)
+		if typ := node.Type(); typ != nil && *typ == "Pages" {
+			for _, kid := range node.ArrayEntry("Kids") {
+				kidRef, ok := kid.(pdfcpu.IndirectRef)
+				if !ok {
+					return errors.Errorf("page node %s has Kid which is not IndirectRef", ref)
+				}
+				if err := walk(kidRef, attrs); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		artboard, err := f.newArtboard(xRefTable, len(artboards), ref, node, attrs)
+		if err != nil {
+			return errors.WithMessagef(err, "page %s", ref)
+		}
+		artboards = append(artboards, artboard)
+		return nil
+	}
+	if err := walk(*root, inheritedAttrs{}); err != nil {
+		return nil, err
+	}
+	matchArtboardSetups(artboards, setups)
+	return artboards, nil
+}
+
+func (f *IllustratorFile) newArtboard(xRefTable *pdfcpu.XRefTable, idx int, ref pdfcpu.IndirectRef, page pdfcpu.Dict, attrs inheritedAttrs) (artboard Artboard, err error) {
+	artboard.Idx = idx
+	artboard.Ref = ref
+	artboard.Name = fmt.Sprintf("Artboard %d", idx+1)
+	if artboard.MediaBox, err = rectangle(xRefTable, attrs.mediaBox); err != nil {
+		return artboard, errors.WithMessage(err, "MediaBox")
+	}
+	if artboard.CropBox, err = rectangle(xRefTable, attrs.cropBox); err != nil {
+		return artboard, errors.WithMessage(err, "CropBox")
+	}
+	if artboard.ArtBox, err = rectangle(xRefTable, page["ArtBox"]); err != nil {
+		return artboard, errors.WithMessage(err, "ArtBox")
+	}
+	if artboard.BleedBox, err = rectangle(xRefTable, page["BleedBox"]); err != nil {
+		return artboard, errors.WithMessage(err, "BleedBox")
+	}
+	if attrs.rotate != nil {
+		rotate, err := xRefTable.DereferenceInteger(attrs.rotate)
+		if err != nil {
+			return artboard, errors.WithMessage(err, "Rotate")
+		}
+		if rotate != nil {
+			artboard.Rotate = rotate.Value()
+		}
+	}
+	if artboard.Resources, err = xRefTable.DereferenceDict(attrs.resources); err != nil {
+		return artboard, errors.WithMessage(err, "Resources")
+	}
+
+	// Contents is either a single stream or an array of streams, which should be concatenated
+	switch contents := page["Contents"].(type) {
+	case pdfcpu.IndirectRef:
+		obj, err := xRefTable.Dereference(contents)
+		if err != nil {
+			return artboard, errors.WithMessage(err, "Contents")
+		}
+		if arr, ok := obj.(pdfcpu.Array); ok {
+			artboard.Contents = contentRefs(arr)
+		} else {
+			artboard.Contents = []int{contents.ObjectNumber.Value()}
+		}
+	case pdfcpu.Array:
+		artboard.Contents = contentRefs(contents)
+	}
+	return artboard, nil
+}
+
+func contentRefs(arr pdfcpu.Array) []int {
+	var refs []int
+	for _, obj := range arr {
+		if ref, ok := obj.(pdfcpu.IndirectRef); ok {
+			refs = append(refs, ref.ObjectNumber.Value())
+		}
+	}
+	return refs
+}
+
+func rectangle(xRefTable *pdfcpu.XRefTable, obj pdfcpu.Object) (*pdfcpu.Rectangle, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	arr, err := xRefTable.DereferenceArray(obj)
+	if err != nil || arr == nil {
+		return nil, err
+	}
+	if len(arr) != 4 {
+		return nil, errors.Errorf("expected 4 numbers, got %d", len(arr))
+	}
+	coords := make(pdfcpu.Array, len(arr))
+	for idx := range arr {
+		if coords[idx], err = xRefTable.Dereference(arr[idx]); err != nil {
+			return nil, err
+		}
+	}
+	return pdfcpu.RectForArray(coords)
+}
+
+// readArtboardSetups reads artboard setups from private data on the first call, nil if there is no private data
+func (f *IllustratorFile) readArtboardSetups(ctx context.Context) ([]*ArtboardSetup, error) {
+	f.artboardSetupsMu.Lock()
+	defer f.artboardSetupsMu.Unlock()
+	if f.artboardSetupsRead || !f.hasPrivateData() {
+		return f.artboardSetups, nil
+	}
+	pd, err := f.privateData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	setups, err := ReadArtboardSetups(ctx, pd)
+	pd.Close()
+	if err != nil {
+		return nil, errors.WithMessage(err, "whilst reading artboard setups")
+	}
+	f.artboardSetups = setups
+	f.artboardSetupsRead = true
+	return setups, nil
+}
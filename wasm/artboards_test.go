@@ -0,0 +1,83 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestArtboards(t *testing.T) {
+	privateData := []string{
+		"%_/ArtboardArray :",
+		"%_/Dictionary :",
+		"%_(First) /UnicodeString (Name) ,",
+		"%_0 0 /RealPoint (PositionPoint1) ,",
+		"%_100 -100 /RealPoint (PositionPoint2) ,",
+		"%_; ,",
+		"%_/Dictionary :",
+		"%_(Second) /UnicodeString (Name) ,",
+		"%_200 0 /RealPoint (PositionPoint1) ,",
+		"%_350 -50 /RealPoint (PositionPoint2) ,",
+		"%_; ,",
+		"%_; (ArtboardArray) ,",
+	}
+	data := testPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /ProcSet [/PDF] >> >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 100 100] /Contents 9 0 R /PieceInfo << /Illustrator 5 0 R >> >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 150 50] /Rotate 90 /Contents [10 0 R 9 0 R] /PieceInfo << /Illustrator 5 0 R >> >>",
+		"<< /Private 6 0 R >>",
+		"<< /AIMetaData 7 0 R /NumBlock 1 /AIPrivateData1 8 0 R >>",
+		testStream("", "%!PS-Adobe-3.0\r"),
+		testStream("", strings.Join(privateData, "\r")),
+		testStream("", "0 g 0 0 10 10 re f"),
+		testStream("", "q Q"),
+	)
+	f, err := ParseContext(context.Background(), bytes.NewReader(data), testConfiguration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.PrivateData.Close()
+	artboards, err := f.Artboards(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artboards) != 2 {
+		t.Fatalf("got %d artboards, expected 2", len(artboards))
+	}
+	expected := []struct {
+		name     string
+		width    float64
+		rotate   int
+		contents []int
+	}{
+		{"First", 100, 0, []int{9}},
+		{"Second", 150, 90, []int{10, 9}},
+	}
+	for idx, artboard := range artboards {
+		want := expected[idx]
+		if artboard.Idx != idx || artboard.Name != want.name || artboard.Setup == nil || artboard.Setup.Name != want.name {
+			t.Errorf("artboard %d got index %d, name %q and setup %+v", idx, artboard.Idx, artboard.Name, artboard.Setup)
+		}
+		if artboard.MediaBox.Width() != want.width || artboard.Rotate != want.rotate || len(artboard.Contents) != len(want.contents) {
+			t.Errorf("artboard %d got %+v", idx, artboard)
+		}
+		for cIdx := range want.contents {
+			if cIdx < len(artboard.Contents) && artboard.Contents[cIdx] != want.contents[cIdx] {
+				t.Errorf("artboard %d got contents %v, expected %v", idx, artboard.Contents, want.contents)
+			}
+		}
+		if artboard.Resources["ProcSet"] == nil {
+			t.Errorf("artboard %d did not inherit Resources", idx)
+		}
+	}
+	// artboards without private data keep default names
+	f.artboardSetups, f.artboardSetupsRead = nil, true
+	if artboards, err = f.Artboards(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if artboards[0].Name != "Artboard 1" || artboards[1].Name != "Artboard 2" || artboards[1].Setup != nil {
+		t.Errorf("got %q and %q", artboards[0].Name, artboards[1].Name)
+	}
+}
@@ -2,6 +2,7 @@ package wasm
 
 import (
 	"bytes"
+	"context"
 	"image/png"
 	"io"
 
@@ -15,13 +16,19 @@ type Image struct {
 	Content []byte
 }
 
-func dumpImage(xRefTable *pdfcpu.XRefTable, objNr int, sd pdfcpu.StreamDict) (img Image, err error) {
-	if err := sd.Decode(); err != nil {
-		return img, errors.Wrapf(err, "while parsing dict contents")
+func dumpImage(ctx context.Context, xRefTable *pdfcpu.XRefTable, objNr int, sd pdfcpu.StreamDict) (img Image, err error) {
+	if err := decodeStream(ctx, &sd); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return img, ctxErr
+		}
+		return img, &ImageDecodeError{objNr, errors.Wrapf(err, "while parsing dict contents")}
+	}
+	if err := ctx.Err(); err != nil {
+		return img, err
 	}
 	ir, ext, err := pdfcpu.RenderImage(xRefTable, &sd, false /* not a thumbnail */, "", objNr)
 	if err != nil {
-		return img, errors.Wrapf(err, "failed decoding image")
+		return img, &ImageDecodeError{objNr, err}
 	}
 	if ext == "" {
 		return img, nil
@@ -32,7 +39,7 @@ func dumpImage(xRefTable *pdfcpu.XRefTable, objNr int, sd pdfcpu.StreamDict) (im
 		ext = "png"
 		dec, err := tiff.Decode(ir)
 		if err != nil {
-			return img, errors.Wrapf(err, "while decoding tiff")
+			return img, &ImageDecodeError{objNr, errors.Wrapf(err, "while decoding tiff")}
 		}
 		if err := png.Encode(buf, dec); err != nil {
 			return img, errors.Wrapf(err, "while encoding png")
@@ -51,14 +58,25 @@ func dumpImage(xRefTable *pdfcpu.XRefTable, objNr int, sd pdfcpu.StreamDict) (im
 
 type ImageReader interface {
 	Read() (Image, error)
+	// ReadContext is Read which stops with ctx.Err() once ctx is done - whilst decoding filters and before rendering,
+	// rendering itself runs to completion once started
+	ReadContext(ctx context.Context) (Image, error)
 }
 
 type imageReader struct {
 	xRefTable *pdfcpu.XRefTable
 	objNr     int
 	sd        pdfcpu.StreamDict
+	limiter   *limiter
 }
 
 func (ctx *imageReader) Read() (Image, error) {
-	return dumpImage(ctx.xRefTable, ctx.objNr, ctx.sd)
+	return ctx.ReadContext(context.Background())
+}
+
+func (ctx *imageReader) ReadContext(c context.Context) (Image, error) {
+	if err := ctx.limiter.checkImage(ctx.xRefTable, ctx.objNr, &ctx.sd); err != nil {
+		return Image{}, err
+	}
+	return dumpImage(c, ctx.xRefTable, ctx.objNr, ctx.sd)
 }
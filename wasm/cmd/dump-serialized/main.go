@@ -1,15 +1,18 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path"
 	"runtime"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
 	"github.com/pkg/errors"
@@ -22,7 +25,16 @@ type Dump struct {
 	StreamDicts map[int]string
 	Bitmaps     map[int]string
 	Fonts       map[int]string
+	Scenes      map[int]string `json:",omitempty"`
 	PrivateData string
+	// PrivateDataAbsent is set instead of PrivateData for plain PDFs
+	PrivateDataAbsent string `json:",omitempty"`
+	// Diagnostics hold strict validation problems tolerated in relaxed mode, malformed XMP metadata and PieceInfo
+	Diagnostics []wasm.Diagnostic `json:",omitempty"`
+	// Legacy is set for pre-CS PostScript files, PrivateData holds the whole PostScript then
+	Legacy *wasm.LegacyInfo `json:",omitempty"`
+	// XMP holds properties of the catalog Metadata stream, thumbnails included
+	XMP *wasm.XMP `json:",omitempty"`
 }
 
 func dumpPrivate(parent string, data wasm.PrivateData) (string, error) {
@@ -41,7 +53,11 @@ func dumpPrivate(parent string, data wasm.PrivateData) (string, error) {
 			}
 			break
 		}
-		if _, err := f.Write(append(data.Bytes(), byte('\r'))); err != nil {
+		line := data.Bytes()
+		if !data.Continued() {
+			line = append(line, byte('\r'))
+		}
+		if _, err := f.Write(line); err != nil {
 			return "", errors.Wrapf(err, "failed writing file %s", f.Name())
 		}
 	}
@@ -55,7 +71,7 @@ type dumpImage struct {
 }
 
 func (params dumpImage) Do(ctx *Ctx) Result {
-	img, err := params.ir.Read()
+	img, err := params.ir.ReadContext(ctx.context)
 	if err != nil {
 		return Result{err: errors.Wrapf(err, "failed decoding image")}
 	}
@@ -97,9 +113,32 @@ func dumpStreamDict(parent string, objId int, dict *pdfcpu.StreamDict) (string,
 	return f.Name(), nil
 }
 
+// dumpScene writes reduced contents of Form XObjects and content streams of artboards, resources are those of the
+// artboard drawing the stream - nil for Form XObjects, which have their own, and other stream dicts, which are skipped
+func dumpScene(parent string, objId int, resources pdfcpu.Dict, data *wasm.IllustratorFile) (string, error) {
+	dict := data.StreamDicts[objId]
+	if subtype := dict.Dict.NameEntry("Subtype"); resources == nil && (subtype == nil || *subtype != "Form") {
+		return "", nil
+	}
+	nodes, err := data.Scene(objId, resources, false)
+	if err != nil {
+		return "", errors.Wrapf(err, "while reducing dict contents")
+	}
+	f, err := os.Create(path.Join(parent, fmt.Sprintf("%d.json", objId)))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed opening tmpfile")
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(nodes); err != nil {
+		return f.Name(), errors.Wrapf(err, "while writing scene")
+	}
+	return f.Name(), nil
+}
+
 const BITMAP_SUBDIR = "bitmaps"
 const FONT_SUBDIR = "fonts"
 const STREAM_CONTENTS_SUBDIR = "_contents"
+const SCENE_SUBDIR = "_scenes"
 
 type Result struct {
 	fName string
@@ -112,8 +151,9 @@ type Worker interface {
 }
 
 type Ctx struct {
-	dir   string
-	stats wasm.Stats
+	context context.Context
+	dir     string
+	stats   wasm.Stats
 
 	bitmapDir  string
 	numBitmaps int
@@ -124,27 +164,32 @@ type Ctx struct {
 	streamContentDir  string
 	numStreamContents int
 
+	sceneDir  string
+	numScenes int
+
 	workers chan Worker
 	results chan Result
 
 	D Dump
 }
 
-func newCtx(base string, data *wasm.SerializedFile) (*Ctx, error) {
+func newCtx(c context.Context, base string, data *wasm.SerializedFile) (*Ctx, error) {
 	dir, err := ioutil.TempDir("", fmt.Sprintf("%s_*", base))
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed opening tmpdir")
 	}
 	numWorkers := runtime.GOMAXPROCS(0) // configurable by GOMAXPROCS env var
 	ctx := Ctx{
+		context:          c,
 		dir:              dir,
 		stats:            wasm.Stats{},
 		bitmapDir:        path.Join(dir, BITMAP_SUBDIR),
 		fontDir:          path.Join(dir, FONT_SUBDIR),
 		streamContentDir: path.Join(dir, STREAM_CONTENTS_SUBDIR),
+		sceneDir:         path.Join(dir, SCENE_SUBDIR),
 		workers:          make(chan Worker, numWorkers),
 		results:          make(chan Result, numWorkers),
-		D:                Dump{data, make(map[int]string), make(map[int]string), make(map[int]string), ""},
+		D:                Dump{data, make(map[int]string), make(map[int]string), make(map[int]string), make(map[int]string), "", "", nil, nil, nil},
 	}
 	if err := os.MkdirAll(ctx.bitmapDir, 0750); err != nil {
 		return nil, errors.Wrapf(err, "failed creating subdir")
@@ -155,6 +200,9 @@ func newCtx(base string, data *wasm.SerializedFile) (*Ctx, error) {
 	if err := os.MkdirAll(ctx.fontDir, 0750); err != nil {
 		return nil, errors.Wrapf(err, "failed creating subdir")
 	}
+	if err := os.MkdirAll(ctx.sceneDir, 0750); err != nil {
+		return nil, errors.Wrapf(err, "failed creating subdir")
+	}
 	for w := 0; w < numWorkers; w++ {
 		go func() {
 			for j := range ctx.workers {
@@ -176,6 +224,9 @@ func (ctx *Ctx) Close() {
 	if ctx.numFonts == 0 {
 		os.Remove(ctx.fontDir)
 	}
+	if ctx.numScenes == 0 {
+		os.Remove(ctx.sceneDir)
+	}
 }
 
 func (ctx *Ctx) dumpBitmaps(bitmaps wasm.Bitmaps) error {
@@ -217,6 +268,9 @@ func (ctx *Ctx) dumpFonts(fonts wasm.Fonts) error {
 
 func (ctx *Ctx) dumpStreamDicts(streamDicts wasm.StreamDicts) error {
 	for objNr, dict := range streamDicts {
+		if err := ctx.context.Err(); err != nil {
+			return err
+		}
 		fName, err := dumpStreamDict(ctx.streamContentDir, objNr, dict)
 		if err != nil {
 			return err
@@ -234,8 +288,42 @@ func (ctx *Ctx) dumpStreamDicts(streamDicts wasm.StreamDicts) error {
 	return nil
 }
 
-func dump(base string, data *wasm.IllustratorFile) error {
-	ctx, err := newCtx(base, data.SerializedFile)
+func (ctx *Ctx) dumpScenes(data *wasm.IllustratorFile) error {
+	artboards, err := data.Artboards(ctx.context)
+	if err != nil {
+		return errors.Wrap(err, "failed listing artboards")
+	}
+	// content streams shared by several artboards are reduced with resources of the first one
+	resources := map[int]pdfcpu.Dict{}
+	for _, artboard := range artboards {
+		for _, objNr := range artboard.Contents {
+			if _, ok := resources[objNr]; !ok {
+				resources[objNr] = artboard.Resources
+				if resources[objNr] == nil {
+					resources[objNr] = pdfcpu.Dict{}
+				}
+			}
+		}
+	}
+	for objNr := range data.StreamDicts {
+		if err := ctx.context.Err(); err != nil {
+			return err
+		}
+		fName, err := dumpScene(ctx.sceneDir, objNr, resources[objNr], data)
+		if err != nil {
+			return errors.Wrapf(err, "failed dumping scene of %d", objNr)
+		}
+		if fName == "" {
+			continue
+		}
+		ctx.D.Scenes[objNr] = path.Join(SCENE_SUBDIR, path.Base(fName))
+		ctx.numScenes += 1
+	}
+	return nil
+}
+
+func dump(c context.Context, base string, data *wasm.IllustratorFile, withScenes bool) error {
+	ctx, err := newCtx(c, base, data.SerializedFile)
 	if err != nil {
 		return errors.Wrap(err, "failed creating context")
 	}
@@ -258,7 +346,18 @@ func dump(base string, data *wasm.IllustratorFile) error {
 		return err
 	}
 	ctx.stats.Observe("stream dicts")
-	if privateFile, err := dumpPrivate(ctx.dir, data.PrivateData); err != nil {
+	if withScenes {
+		if err := ctx.dumpScenes(data); err != nil {
+			return err
+		}
+		ctx.stats.Observe("scenes")
+	}
+	ctx.D.Diagnostics = data.Diagnostics
+	ctx.D.Legacy = data.Legacy
+	ctx.D.XMP = data.XMP
+	if data.PrivateDataAbsent != nil {
+		ctx.D.PrivateDataAbsent = data.PrivateDataAbsent.Error()
+	} else if privateFile, err := dumpPrivate(ctx.dir, data.PrivateData); err != nil {
 		return errors.Wrapf(err, "while dumping private data")
 	} else {
 		ctx.D.PrivateData = privateFile
@@ -273,20 +372,22 @@ func dump(base string, data *wasm.IllustratorFile) error {
 	return nil
 }
 
-func run(conf *wasm.Configuration, files ...string) (exitCode int) {
+func run(ctx context.Context, conf *wasm.Configuration, files ...string) (exitCode int) {
 	pprof := os.Getenv("AICPU_DUMP_PPROF")
 	if len(pprof) != 0 {
 		defer profile.Start(profile.CPUProfile, profile.ProfilePath(pprof)).Stop()
 	}
 
+	withScenes := len(os.Getenv("AICPU_DUMP_SCENES")) != 0
+
 	for _, file := range files {
 		fmt.Printf("parsing %s ...\n", file)
-		data, err := wasm.ParseFile(file, conf)
+		data, err := wasm.ParseFileContext(ctx, file, conf)
 		if err != nil {
 			fmt.Println(err)
 			return 1
 		}
-		if err := dump(path.Base(file), data); err != nil {
+		if err := dump(ctx, path.Base(file), data, withScenes); err != nil {
 			fmt.Println(err)
 			return 2
 		}
@@ -298,17 +399,28 @@ func run(conf *wasm.Configuration, files ...string) (exitCode int) {
 func main() {
 	conf := wasm.NewConfiguration()
 	conf.WithPrivateData = true
+	conf.AllowMissingPrivateData = true
+	conf.ValidationPolicy = wasm.ValidationRetryRelaxed
+	conf.AllowLegacy = true
 
-	bufferSize, err := strconv.Atoi(os.Getenv("AICPU_WASM_BUFFER_SIZE"))
-	if err == nil {
+	// long lines are split, so the default is enough - it bounds the size of a single write
+	if bufferSize, err := strconv.Atoi(os.Getenv("AICPU_WASM_BUFFER_SIZE")); err == nil {
 		wasm.BufferSize = bufferSize
-	} else {
-		wasm.BufferSize = 512 * 1024 * 1024
 	}
 
 	if len(os.Args) < 2 {
 		os.Exit(127) // TODO: Notify about usage?
 	}
 
-	os.Exit(run(conf, os.Args[1:]...))
+	// interrupt stops parsing and dumping of the current file
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if timeout, err := time.ParseDuration(os.Getenv("AICPU_DUMP_TIMEOUT")); err == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	exitCode := run(ctx, conf, os.Args[1:]...)
+	stop()
+	os.Exit(exitCode)
 }
@@ -2,16 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"runtime/debug"
 	"strconv"
+	"sync"
 	"syscall/js"
 
 	"github.com/pkg/errors"
 	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm"
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
 )
 
 // https://withblue.ink/2020/10/03/go-webassembly-http-requests-and-promises.html
@@ -23,7 +26,7 @@ func Promisify(f func() (interface{}, error)) js.Value {
 		go func() {
 			val, err := f()
 			if err != nil {
-				reject.Invoke(err.Error())
+				reject.Invoke(jsError(err))
 			} else {
 				resolve.Invoke(val)
 			}
@@ -34,14 +37,23 @@ func Promisify(f func() (interface{}, error)) js.Value {
 	return promiseConstructor.New(handler)
 }
 
-func bitmapFetchers(bitmaps wasm.Bitmaps) (funcs map[string]interface{}) {
+// jsError converts err to JS Error with code (see wasm.ErrorCode) and objNr when known, see wasm.ErrorProperties
+func jsError(err error) js.Value {
+	jsErr := js.Global().Get("Error").New(err.Error())
+	for key, val := range wasm.ErrorProperties(err) {
+		jsErr.Set(key, val)
+	}
+	return jsErr
+}
+
+func bitmapFetchers(ctx context.Context, bitmaps wasm.Bitmaps) (funcs map[string]interface{}) {
 	funcs = make(map[string]interface{})
 	for objNr, img := range bitmaps {
 		idx := fmt.Sprintf("%d", objNr)
 		img := img
 		funcs[idx] = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 			return Promisify(func() (interface{}, error) {
-				img, err := img.Read()
+				img, err := img.ReadContext(ctx)
 				if err != nil {
 					return "", err
 				}
@@ -94,10 +106,70 @@ func streamDictFetcherWrapper(dicts wasm.StreamDicts) js.Func {
 	})
 }
 
+func operatorsFetcherWrapper(dicts wasm.StreamDicts) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return Promisify(func() (interface{}, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("Invalid no of arguments passed: %d", len(args))
+			}
+			dict, ok := dicts[args[0].Int()]
+			if !ok {
+				return nil, fmt.Errorf("Unknown stream dict: %d", args[0].Int())
+			}
+			ops, err := contents.ParseStreamDict(dict)
+			if err != nil {
+				return nil, err
+			}
+			bs, err := json.Marshal(ops)
+			if err != nil {
+				return nil, err
+			}
+			return string(bs), nil
+		})
+	})
+}
+
+// privateDataIterators returns a function creating a new iterator over private data on each call. The first iterator
+// reads PrivateData as it's decompressed, later ones read private data indexed once they are first advanced.
+func privateDataIterators(ctx context.Context, data *wasm.IllustratorFile) js.Func {
+	called := false
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		open := func() (wasm.PrivateData, error) { return data.PrivateData, nil }
+		if called && data.PrivateData != nil {
+			open = func() (wasm.PrivateData, error) {
+				indexed, err := data.IndexedPrivateData(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return indexed.Reader(ctx), nil
+			}
+		}
+		called = true
+		return map[string]interface{}{
+			"next": next(open),
+		}
+	})
+}
+
 // https://javascript.info/async-iterators-generators
-func next(priv wasm.PrivateData) js.Func {
+func next(open func() (wasm.PrivateData, error)) js.Func {
+	var priv wasm.PrivateData
+	opened := false
 	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		return Promisify(func() (interface{}, error) {
+			if !opened {
+				var err error
+				if priv, err = open(); err != nil {
+					return nil, errors.WithMessage(err, "opening private data")
+				}
+				opened = true
+			}
+			if priv == nil {
+				return map[string]interface{}{
+					"done":  true,
+					"value": NewUint8ArrayFromGo(nil).ToJS(),
+				}, nil
+			}
 			buffer := bytes.NewBuffer([]byte{})
 			ok := true
 			for i := 0; i < 100; i += 1 {
@@ -108,7 +180,11 @@ func next(priv wasm.PrivateData) js.Func {
 						return nil, errors.Wrapf(err, "reading line")
 					}
 				}
-				buffer.Write(append(priv.Bytes(), '\r'))
+				buffer.Write(priv.Bytes())
+				// parts of long lines are glued together by the reader
+				if !priv.Continued() {
+					buffer.WriteByte('\r')
+				}
 				if !ok {
 					break
 				}
@@ -121,16 +197,57 @@ func next(priv wasm.PrivateData) js.Func {
 	})
 }
 
+// abortContext returns context cancelled once AbortSignal is aborted - it's kept for the lifetime of parsed file,
+// so aborting stops reading private data and bitmaps as well. release removes the listener, it's safe to call repeatedly.
+func abortContext(signal js.Value) (ctx context.Context, release func()) {
+	if signal.IsUndefined() || signal.IsNull() {
+		return context.Background(), func() {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if signal.Get("aborted").Bool() {
+		cancel()
+		return ctx, func() {}
+	}
+	var once sync.Once
+	var onAbort js.Func
+	release = func() {
+		once.Do(func() {
+			signal.Call("removeEventListener", "abort", onAbort)
+			onAbort.Release()
+		})
+	}
+	onAbort = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer release()
+		cancel()
+		return nil
+	})
+	signal.Call("addEventListener", "abort", onAbort)
+	return ctx, release
+}
+
 func jsWrapper(this js.Value, args []js.Value) interface{} {
 	return Promisify(func() (interface{}, error) {
-		if len(args) != 1 {
+		if len(args) != 1 && len(args) != 2 {
 			return nil, fmt.Errorf("Invalid no of arguments passed: %d", len(args))
 		}
+		signal := js.Undefined()
+		if len(args) == 2 {
+			signal = args[1]
+		}
+		ctx, release := abortContext(signal)
+		keepListener := false
+		defer func() {
+			// the listener stays for bitmaps of the parsed file, otherwise nothing uses ctx anymore
+			if !keepListener {
+				release()
+			}
+		}()
 
 		conf := wasm.NewConfiguration()
 		conf.WithPrivateData = true
+		conf.AllowMissingPrivateData = true
 
-		data, err := wasm.Parse(NewUint8ArrayFromJS(args[0]), conf)
+		data, err := wasm.ParseContext(ctx, NewUint8ArrayFromJS(args[0]), conf)
 		if err != nil {
 			fmt.Printf("unable to parse: %s\n", err)
 			return nil, err
@@ -140,15 +257,19 @@ func jsWrapper(this js.Value, args []js.Value) interface{} {
 			fmt.Printf("unable to serialize: %s\n", err)
 			return nil, err
 		}
-		return map[string]interface{}{
+		ret := map[string]interface{}{
 			"value": string(bs),
-			"privateData": map[string]interface{}{
-				"next": next(data.PrivateData),
-			},
+			"privateData": privateDataIterators(ctx, data),
 			"streamDict": streamDictFetcherWrapper(data.StreamDicts),
-			"bitmaps":    bitmapFetchers(data.Bitmaps),
+			"operators":  operatorsFetcherWrapper(data.StreamDicts),
+			"bitmaps":    bitmapFetchers(ctx, data.Bitmaps),
 			"fonts":      fontFetchers(data.Fonts),
-		}, nil
+		}
+		if data.PrivateDataAbsent != nil {
+			ret["privateDataAbsent"] = data.PrivateDataAbsent.Error()
+		}
+		keepListener = true
+		return ret, nil
 	})
 }
 
@@ -158,7 +279,7 @@ func main() {
 	if err == nil {
 		wasm.BufferSize = bufferSize
 	} else {
-		wasm.BufferSize = 512 * 1024 * 1024
+		bufferSize = wasm.BufferSize
 	}
 	exit := make(chan bool)
 	js.Global().Set("IllustratorParser", map[string]interface{}{
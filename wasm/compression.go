@@ -0,0 +1,62 @@
+package wasm
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Decompressor wraps compressed private data - everything after its header, across AIPrivateData blocks until another header
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+const (
+	zlibHeader = "%AI12_CompressedData"
+	zstdHeader = "%AI24_ZStandard_Data"
+)
+
+var (
+	decompressorsMu sync.RWMutex
+	decompressors   = map[string]Decompressor{
+		zstdHeader: newZstdReader,
+		zlibHeader: newZlibReader,
+	}
+)
+
+// RegisterDecompressor makes private data compressed by an unknown method readable instead of failing with
+// ErrUnsupportedCompression, header is the whole marker the first compressed block starts with, e.g. %AI12_CompressedData.
+// Unregistered methods are recognised only by headers named like %AI12_CompressedData or %AI24_ZStandard_Data.
+func RegisterDecompressor(header string, d Decompressor) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+	decompressors[header] = d
+}
+
+// lookupDecompressor returns the longest registered header content starts with, empty if there is none - so that
+// a header which is a prefix of another one does not shadow it
+func lookupDecompressor(content []byte) (string, Decompressor) {
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+	var found string
+	var decompressor Decompressor
+	for header, d := range decompressors {
+		if len(header) > len(found) && bytes.HasPrefix(content, []byte(header)) {
+			found, decompressor = header, d
+		}
+	}
+	return found, decompressor
+}
+
+func newZstdReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+func newZlibReader(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
@@ -0,0 +1,162 @@
+package wasm
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestCompressionHeader(t *testing.T) {
+	tests := []struct {
+		content string
+		header  string
+	}{
+		{"%AI12_CompressedData\x78\x9c", "%AI12_CompressedData"},
+		{"%AI24_ZStandard_Data\x28\xb5\x2f\xfd", "%AI24_ZStandard_Data"},
+		{"%AI30_Brotli_Data\x0b\x02", "%AI30_Brotli_Data"},
+		{"%AI31_LZ4CompressedDataxyz", "%AI31_LZ4CompressedData"},
+		{"%AI9_PrivateDataBegin\r", ""},
+		{"%AI9_PrivateData\r", ""},
+		{"%AI5_BeginData\r", ""},
+		{"%!PS-Adobe-3.0\r", ""},
+	}
+	for _, tt := range tests {
+		if got := compressionHeaderRe.FindString(tt.content); got != tt.header {
+			t.Errorf("%q got %q, expected %q", tt.content, got, tt.header)
+		}
+	}
+}
+
+func TestLookupDecompressorLongestHeader(t *testing.T) {
+	long := "%AI12_CompressedData2"
+	RegisterDecompressor(long, func(r io.Reader) (io.ReadCloser, error) { return ioutil.NopCloser(r), nil })
+	defer func() {
+		decompressorsMu.Lock()
+		delete(decompressors, long)
+		decompressorsMu.Unlock()
+	}()
+	// map order varies between runs, the longest header has to win every time
+	for idx := 0; idx < 20; idx++ {
+		if header, _ := lookupDecompressor([]byte(long + "data")); header != long {
+			t.Fatalf("got %q, expected %q", header, long)
+		}
+		if header, _ := lookupDecompressor([]byte(zlibHeader + "\x78\x9c")); header != zlibHeader {
+			t.Fatalf("got %q, expected %q", header, zlibHeader)
+		}
+	}
+	if header, d := lookupDecompressor([]byte("%AI9_PrivateDataBegin")); header != "" || d != nil {
+		t.Errorf("got %q for ordinary marker", header)
+	}
+}
+
+func TestUnsupportedCompression(t *testing.T) {
+	tests := []struct {
+		name        string
+		firstLine   string
+		unsupported bool
+	}{
+		{"ordinary marker", "%AI9_PrivateDataBegin", false},
+		{"unknown header", "%AI30_Brotli_Data\x0b\x02\x80", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := testIllustratorPDF([]string{tt.firstLine, "%AI5_BeginLayer"}, "0 0 100 100", "", "")
+			ctx := context.Background()
+			f, err := ParseContext(ctx, bytes.NewReader(data), testConfiguration())
+			if err == nil {
+				defer f.PrivateData.Close()
+				var pd PrivateData
+				if pd, err = f.privateData(ctx); err == nil {
+					for pd.Scan() {
+					}
+					err = pd.Err()
+					pd.Close()
+				}
+			}
+			if got := errors.Is(err, ErrUnsupportedCompression); got != tt.unsupported {
+				t.Errorf("got error %v, expected unsupported compression %v", err, tt.unsupported)
+			}
+		})
+	}
+}
+
+// testBlocksPDF returns a single page PDF with private data stored in blocks, which are stream objects from 7 on
+func testBlocksPDF(blocks ...string) []byte {
+	private := fmt.Sprintf("<< /AIMetaData 6 0 R /NumBlock %d", len(blocks))
+	for idx := range blocks {
+		private += fmt.Sprintf(" /AIPrivateData%d %d 0 R", idx+1, idx+7)
+	}
+	return testPDF(append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 100 100] >>",
+		"<< /Type /Page /Parent 2 0 R /PieceInfo << /Illustrator 4 0 R >> >>",
+		"<< /Private 5 0 R >>",
+		private + " >>",
+		testStream("", "%!PS-Adobe-3.0\r"),
+	}, blocks...)...)
+}
+
+func TestCompressedThenRawBlocks(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write([]byte("compressed one\rcompressed two\r"))
+	zw.Close()
+	half := compressed.Len() / 2
+	first, second := string(compressed.Bytes()[:half]), string(compressed.Bytes()[half:])
+
+	tests := []struct {
+		name   string
+		blocks []string
+		lines  []string
+	}{
+		{"raw block after compressed", []string{
+			testStream("", zlibHeader+first),
+			testStream("", second),
+			testStream("/Filter /ASCIIHexDecode", hex.EncodeToString([]byte("raw line"))+">"),
+		}, []string{"%!PS-Adobe-3.0", "compressed one", "compressed two", "raw line"}},
+		{"compressed block after raw", []string{
+			testStream("/Filter /ASCIIHexDecode", hex.EncodeToString([]byte("raw line\r"))+">"),
+			testStream("", zlibHeader+first),
+			testStream("", second),
+		}, []string{"%!PS-Adobe-3.0", "raw line", "compressed one", "compressed two"}},
+		{"unknown header after compressed", []string{
+			testStream("", zlibHeader+first+second),
+			testStream("", "%AI30_Brotli_Data\x0b\x02\x80"),
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f, err := ParseContext(ctx, bytes.NewReader(testBlocksPDF(tt.blocks...)), testConfiguration())
+			if tt.lines == nil {
+				if !errors.Is(err, ErrUnsupportedCompression) {
+					t.Errorf("got %v, expected %v", err, ErrUnsupportedCompression)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lines := testReadLines(t, f.PrivateData); !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("got %q, expected %q", lines, tt.lines)
+			}
+		})
+	}
+}
+
+func TestShortBlocks(t *testing.T) {
+	f, err := ParseContext(context.Background(), bytes.NewReader(testBlocksPDF(testStream("", "%"), testStream("", "A"))), testConfiguration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := testReadLines(t, f.PrivateData); !reflect.DeepEqual(lines, []string{"%!PS-Adobe-3.0", "%A"}) {
+		t.Errorf("got %q", lines)
+	}
+}
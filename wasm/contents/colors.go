@@ -0,0 +1,195 @@
+package contents
+
+import (
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// see 8.6.5.5 ICCBased Colour Spaces
+func (r *Reducer) pickAlternateICCBased(params pdfcpu.Object) string {
+	var dict pdfcpu.Dict
+	switch v := r.deref(params).(type) {
+	case pdfcpu.StreamDict:
+		dict = v.Dict
+	case pdfcpu.Dict:
+		dict = v
+	}
+	// (Optional) An alternate colour space that shall be used in case the one
+	// specified in the stream data is not supported. If this entry is omitted
+	// the colour space that shall be used is DeviceGray, DeviceRGB, or
+	// DeviceCMYK, depending on whether the value of N is 1, 3, or 4, respectively
+	if alternate, ok := dict["Alternate"]; ok {
+		return r.mapAlternate(alternate)
+	}
+	if n := dict.IntEntry("N"); n != nil {
+		switch *n {
+		case 1:
+			return "DeviceGray"
+		case 3:
+			return "DeviceRGB"
+		case 4:
+			return "DeviceCMYK"
+		}
+	}
+	return "DeviceRGB"
+}
+
+// mapAlternate reduces colour space to a name of the one that would be used by poppler
+func (r *Reducer) mapAlternate(colorSpace pdfcpu.Object) string {
+	switch cs := r.deref(colorSpace).(type) {
+	case pdfcpu.Name:
+		return cs.Value()
+	case pdfcpu.Array:
+		if len(cs) == 0 {
+			return ""
+		}
+		family, _ := cs[0].(pdfcpu.Name)
+		switch {
+		case family == "ICCBased" && len(cs) > 1:
+			return r.pickAlternateICCBased(cs[1])
+		// see 8.6.6.4 Separation Colour Spaces
+		case family == "Separation" && len(cs) > 2:
+			return r.mapAlternate(cs[2])
+		default:
+			return family.Value()
+		}
+	}
+	return ""
+}
+
+func isPattern(colorSpace interface{}) bool {
+	switch cs := colorSpace.(type) {
+	case string:
+		return cs == "Pattern"
+	case pdfcpu.Name:
+		return cs == "Pattern"
+	case pdfcpu.Array:
+		return len(cs) > 0 && cs[0] == pdfcpu.Name("Pattern")
+	}
+	return false
+}
+
+func defaultShape(colorSpace interface{}, val float64) []interface{} {
+	switch colorSpace {
+	case "DeviceGray", pdfcpu.Name("DeviceGray"):
+		return []interface{}{val}
+	case "DeviceCMYK", pdfcpu.Name("DeviceCMYK"):
+		return []interface{}{val, val, val, 1.0}
+	}
+	return []interface{}{val, val, val}
+}
+
+func defaultColor(colorSpace interface{}) []interface{} {
+	return defaultShape(colorSpace, 0)
+}
+
+// e.g. with Separation there's only one value instead of 3
+// because we are forcibly mapping it to an alternate space
+func mapAlternateShape(colorSpace interface{}, vals []interface{}) []interface{} {
+	if len(vals) == 1 {
+		if val, ok := vals[0].(float64); ok {
+			return defaultShape(colorSpace, val)
+		}
+	}
+	return vals
+}
+
+// decodeColorSpace resolves colour space operand of CS/cs - either a family name or a ColorSpace resource
+func (r *Reducer) decodeColorSpace(args []Operand) (interface{}, error) {
+	name, err := decodeName(args)
+	if err != nil {
+		return nil, err
+	}
+	var colorSpace pdfcpu.Object = pdfcpu.Name(name)
+	if val := r.resource("ColorSpace", name); val != nil {
+		colorSpace = val
+	}
+	if arr, ok := colorSpace.(pdfcpu.Array); ok && len(arr) == 1 {
+		colorSpace = r.deref(arr[0])
+	}
+	if r.strictPopplerCompat {
+		return r.mapAlternate(colorSpace), nil
+	}
+	return colorSpace, nil
+}
+
+// 8.6.8 Colour Operators
+func (r *Reducer) applyColourOperators(op Operator) error {
+	g := r.currentGraphics()
+	switch op.Name {
+	case "CS", "cs":
+		colorSpace, err := r.decodeColorSpace(op.Args)
+		if err != nil {
+			return err
+		}
+		if op.Name == "CS" {
+			g.ColorSpaceStroking, g.ColorStroking = colorSpace, defaultColor(colorSpace)
+		} else {
+			g.ColorSpaceNonStroking, g.ColorNonStroking = colorSpace, defaultColor(colorSpace)
+		}
+	case "SCN":
+		return r.decodeScn(op.Args, &g.ColorStroking, g.ColorSpaceStroking)
+	case "scn":
+		return r.decodeScn(op.Args, &g.ColorNonStroking, g.ColorSpaceNonStroking)
+	case "SC":
+		return decodeSc(op.Args, 0, &g.ColorStroking)
+	case "sc":
+		return decodeSc(op.Args, 0, &g.ColorNonStroking)
+	case "G":
+		g.ColorSpaceStroking = "DeviceGray"
+		return decodeSc(op.Args, 1, &g.ColorStroking)
+	case "g":
+		g.ColorSpaceNonStroking = "DeviceGray"
+		return decodeSc(op.Args, 1, &g.ColorNonStroking)
+	case "RG":
+		g.ColorSpaceStroking = "DeviceRGB"
+		return decodeSc(op.Args, 3, &g.ColorStroking)
+	case "rg":
+		g.ColorSpaceNonStroking = "DeviceRGB"
+		return decodeSc(op.Args, 3, &g.ColorNonStroking)
+	case "K":
+		g.ColorSpaceStroking = "DeviceCMYK"
+		return decodeSc(op.Args, 4, &g.ColorStroking)
+	case "k":
+		g.ColorSpaceNonStroking = "DeviceCMYK"
+		return decodeSc(op.Args, 4, &g.ColorNonStroking)
+	}
+	return nil
+}
+
+// If the current colour space is a Pattern colour space, name shall be the
+// name of an entry in the Pattern subdictionary of the current resource
+// dictionary. For an uncoloured tiling pattern, c1...cn shall be component
+// values specifying a colour in the pattern’s underlying colour space.
+// Otherwise operands c1...cn shall be numbers.
+func (r *Reducer) decodeScn(args []Operand, color *[]interface{}, colorSpace interface{}) error {
+	var vals []interface{}
+	var err error
+	if isPattern(colorSpace) {
+		vals, err = decodePattern(args)
+	} else {
+		vals, err = decodeColor(args)
+	}
+	if err != nil {
+		return err
+	}
+	if r.strictPopplerCompat {
+		vals = mapAlternateShape(colorSpace, vals)
+	}
+	*color = vals
+	return nil
+}
+
+// decodeSc sets color to components of SC, G, RG or K and their non-stroking counterparts. Device colour operators
+// take exactly components operands, 0 leaves the count to the current colour space.
+func decodeSc(args []Operand, components int, color *[]interface{}) error {
+	if components > 0 && len(args) != components {
+		return errors.Errorf("%d operands given to device colour, %d expected", len(args), components)
+	}
+	vals, err := decodeColor(args)
+	if err != nil {
+		return err
+	}
+	*color = vals
+	return nil
+}
@@ -0,0 +1,88 @@
+package contents
+
+import (
+	"github.com/pkg/errors"
+)
+
+func decodeNumbers(args []Operand) ([]float64, error) {
+	ret := make([]float64, 0, len(args))
+	for _, arg := range args {
+		num, ok := arg.(Number)
+		if !ok {
+			return nil, errors.Errorf("bogus operand '%s' in decodeNumbers", arg.OperandType())
+		}
+		ret = append(ret, float64(num))
+	}
+	return ret, nil
+}
+
+func decodeNumber(args []Operand) (float64, error) {
+	nums, err := decodeNumbers(args)
+	if err != nil {
+		return 0, err
+	}
+	if len(nums) == 0 {
+		return 0, errors.New("missing operand in decodeNumber")
+	}
+	return nums[0], nil
+}
+
+func decodeName(args []Operand) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New("missing operand in decodeName")
+	}
+	name, ok := args[0].(Name)
+	if !ok {
+		return "", errors.Errorf("bogus operand '%s' in decodeName", args[0].OperandType())
+	}
+	return string(name), nil
+}
+
+// decodeColor returns components c1...cn, typed as interface{} to share the slice shape with pattern colours
+func decodeColor(args []Operand) ([]interface{}, error) {
+	nums, err := decodeNumbers(args)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]interface{}, len(nums))
+	for idx, num := range nums {
+		ret[idx] = num
+	}
+	return ret, nil
+}
+
+// decodePattern returns components c1...cn followed by pattern name
+func decodePattern(args []Operand) ([]interface{}, error) {
+	if len(args) == 0 {
+		return nil, errors.New("empty argument array given to Pattern")
+	}
+	ret, err := decodeColor(args[:len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+	name, err := decodeName(args[len(args)-1:])
+	if err != nil {
+		return nil, errors.WithMessage(err, "in decodePattern last argument")
+	}
+	return append(ret, name), nil
+}
+
+// decodeDashPattern returns dash array followed by dash phase
+func decodeDashPattern(args []Operand) ([]interface{}, error) {
+	ret := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		switch val := arg.(type) {
+		case Array:
+			nums, err := decodeNumbers(val)
+			if err != nil {
+				return nil, errors.WithMessage(err, "in DashPattern array")
+			}
+			ret = append(ret, nums)
+		case Number:
+			ret = append(ret, float64(val))
+		default:
+			return nil, errors.Errorf("bogus operand '%s' in DashPattern", arg.OperandType())
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,136 @@
+package contents
+
+// Node is a single element of the tree produced by Reducer: *Path, *TextGroup, *MarkedContext, *XObject, *Shading or *InlineImageNode.
+// Field names follow src/contents/entities.ts, so JSON produced from either implementation has the same shape.
+type Node interface {
+	NodeType() string
+}
+
+// GraphicsState is a snapshot of 8.4 Graphics State at the moment given node was painted.
+type GraphicsState struct {
+	CTM                   []float64
+	ClippingPath          []*Path
+	ColorSpaceStroking    interface{} // name of the colour space or, unless in strict poppler compat mode, a resource it refers to
+	ColorSpaceNonStroking interface{}
+	ColorStroking         []interface{} // numbers, optionally followed by pattern name
+	ColorNonStroking      []interface{}
+	TextCharSpace         float64
+	TextWordSpace         float64
+	TextScale             float64
+	TextLeading           float64
+	TextFont              string
+	TextFontSize          float64
+	TextRender            float64
+	TextRise              float64
+	LineWidth             float64
+	LineCap               float64
+	LineJoin              float64
+	MiterLimit            float64
+	DashPattern           []interface{} // dash array followed by dash phase
+	RenderingIntent       string
+	Flatness              float64
+	StrokeAdjustment      bool
+	BlendMode             string
+	SoftMask              interface{}
+	AlphaConstant         float64
+	AlphaSource           bool
+	SpecifiedParameters   interface{} // name of ExtGState or, unless in strict poppler compat mode, the dictionary itself
+}
+
+func NewGraphicsState() GraphicsState {
+	return GraphicsState{
+		ColorSpaceStroking:    "DeviceGray",
+		ColorSpaceNonStroking: "DeviceGray",
+		ColorStroking:         []interface{}{0.0, 0.0, 0.0},
+		ColorNonStroking:      []interface{}{0.0, 0.0, 0.0},
+		TextScale:             100,
+		LineWidth:             1,
+		MiterLimit:            10,
+		DashPattern:           []interface{}{[]float64{}, 0.0},
+		RenderingIntent:       "RelativeColorimetric",
+		BlendMode:             "Normal",
+		AlphaConstant:         1,
+	}
+}
+
+type MarkedContext struct {
+	Type       string
+	Kids       []Node
+	Tag        string
+	Properties string `json:",omitempty"`
+}
+
+func (*MarkedContext) NodeType() string { return "MarkedContext" }
+
+type XObject struct {
+	Type          string
+	Name          string
+	GraphicsState GraphicsState
+}
+
+func (*XObject) NodeType() string { return "XObject" }
+
+type Shading struct {
+	Type          string
+	Name          string
+	GraphicsState GraphicsState
+}
+
+func (*Shading) NodeType() string { return "Shading" }
+
+// InlineImageNode has no counterpart in src/contents - inline images are not handled there at all.
+type InlineImageNode struct {
+	Type          string
+	Image         InlineImage
+	GraphicsState GraphicsState
+}
+
+func (*InlineImageNode) NodeType() string { return "InlineImage" }
+
+type FillRule string
+
+const (
+	FillRuleNonZeroWindingNumber FillRule = "nonzero-winding-number"
+	FillRuleEvenOdd              FillRule = "even-odd"
+)
+
+type Path struct {
+	Type          string
+	GraphicsState GraphicsState
+	Subpaths      []Subpath
+	FillRule      FillRule
+	Fill          bool
+	Stroke        bool
+}
+
+func (*Path) NodeType() string { return "Path" }
+
+// Subpath is either a "Rect" (with Coords) or a "Path" (with Points)
+type Subpath struct {
+	Type   string
+	Coords []float64   `json:",omitempty"`
+	Points []PathPoint `json:",omitempty"`
+	Closed bool
+}
+
+// PathPoint is one of "Move", "Line" or "Curve" segments
+type PathPoint struct {
+	Type   string
+	Coords []float64
+}
+
+type TextGroup struct {
+	Type  string
+	Texts []Text
+}
+
+func (*TextGroup) NodeType() string { return "TextGroup" }
+
+// Text holds operands of text showing operator as found in the content stream - string for Tj, array for TJ.
+// Decoding them requires font, which is out of scope of this package.
+type Text struct {
+	GraphicsState  GraphicsState
+	TextMatrix     []float64
+	TextLineMatrix []float64
+	Text           Operand
+}
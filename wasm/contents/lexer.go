@@ -0,0 +1,143 @@
+package contents
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// 7.2 - Lexical Conventions
+func isWhitespace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func isRegular(c byte) bool {
+	return !isWhitespace(c) && !isDelimiter(c)
+}
+
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func (lx *lexer) eof() bool {
+	return lx.pos >= len(lx.data)
+}
+
+func (lx *lexer) peek() byte {
+	return lx.data[lx.pos]
+}
+
+func (lx *lexer) errorf(format string, args ...interface{}) error {
+	return errors.Errorf(format+" (at offset %d)", append(args, lx.pos)...)
+}
+
+// skipWhitespace gobbles both whitespace and comments, neither of which carries meaning in content streams
+func (lx *lexer) skipWhitespace() {
+	for !lx.eof() {
+		c := lx.peek()
+		if isWhitespace(c) {
+			lx.pos++
+			continue
+		}
+		if c != '%' {
+			return
+		}
+		for !lx.eof() && lx.peek() != '\r' && lx.peek() != '\n' {
+			lx.pos++
+		}
+	}
+}
+
+// regular returns a run of regular characters - a number, keyword or (after solidus) a name
+func (lx *lexer) regular() []byte {
+	start := lx.pos
+	for !lx.eof() && isRegular(lx.peek()) {
+		lx.pos++
+	}
+	return lx.data[start:lx.pos]
+}
+
+func (lx *lexer) name() (Name, error) {
+	lx.pos++ // solidus
+	raw := lx.regular()
+	if bytes.IndexByte(raw, '#') < 0 {
+		return Name(raw), nil
+	}
+	ret := make([]byte, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '#' {
+			ret = append(ret, raw[i])
+			continue
+		}
+		if i+2 >= len(raw) {
+			return "", lx.errorf("truncated escape in name '%s'", raw)
+		}
+		val, err := strconv.ParseUint(string(raw[i+1:i+3]), 16, 8)
+		if err != nil {
+			return "", lx.errorf("invalid escape in name '%s'", raw)
+		}
+		ret = append(ret, byte(val))
+		i += 2
+	}
+	return Name(ret), nil
+}
+
+func (lx *lexer) literalString() (LiteralString, error) {
+	lx.pos++ // (
+	start := lx.pos
+	open := 1
+	for !lx.eof() {
+		switch lx.peek() {
+		case '\\':
+			lx.pos++
+		case '(':
+			open++
+		case ')':
+			open--
+		}
+		lx.pos++
+		if open == 0 {
+			return LiteralString(lx.data[start : lx.pos-1]), nil
+		}
+	}
+	return nil, lx.errorf("unterminated literal string")
+}
+
+func (lx *lexer) hexadecimalString() (HexadecimalString, error) {
+	lx.pos++ // <
+	ret := make([]byte, 0, 32)
+	for !lx.eof() {
+		c := lx.peek()
+		lx.pos++
+		if c == '>' {
+			return HexadecimalString(ret), nil
+		}
+		if !isWhitespace(c) {
+			ret = append(ret, c)
+		}
+	}
+	return "", lx.errorf("unterminated hexadecimal string")
+}
+
+// keyword checks whether data at cursor is the given keyword followed by whitespace, delimiter or EOF
+func (lx *lexer) keyword(kw string) bool {
+	end := lx.pos + len(kw)
+	if end > len(lx.data) || string(lx.data[lx.pos:end]) != kw {
+		return false
+	}
+	return end == len(lx.data) || !isRegular(lx.data[end])
+}
@@ -0,0 +1,173 @@
+package contents
+
+import (
+	"encoding/json"
+)
+
+// Operand is one of the PDF objects that may appear as an argument of a content stream operator.
+// See 7.3 - Objects in https://web.archive.org/web/20220226063926/https://www.adobe.com/content/dam/acom/en/devnet/pdf/pdfs/PDF32000_2008.pdf
+type Operand interface {
+	OperandType() OperandType
+}
+
+type OperandType string
+
+// names are kept in line with OperandType from src/syntax/interfaces.ts
+const (
+	OperandNumber            OperandType = "number"
+	OperandName              OperandType = "name"
+	OperandLiteralString     OperandType = "literal string"
+	OperandHexadecimalString OperandType = "hexadecimal string"
+	OperandArray             OperandType = "array"
+	OperandDict              OperandType = "dictionary"
+	OperandBoolean           OperandType = "boolean"
+	OperandNull              OperandType = "null"
+	OperandInlineImage       OperandType = "inline image"
+)
+
+type Number float64
+
+// Name is stored without the leading solidus and with #xx escapes resolved.
+type Name string
+
+// LiteralString holds bytes between the outermost parentheses, escape sequences are left intact - use Unescape to resolve them.
+type LiteralString []byte
+
+// HexadecimalString holds hex digits between angle brackets, whitespace removed.
+type HexadecimalString string
+
+type Array []Operand
+
+type Dict map[string]Operand
+
+type Boolean bool
+
+type Null struct{}
+
+// InlineImage is the only argument of BI operator - the whole BI ... ID ... EI sequence is collapsed into it.
+type InlineImage struct {
+	Dict Dict
+	Data []byte
+}
+
+func (Number) OperandType() OperandType            { return OperandNumber }
+func (Name) OperandType() OperandType              { return OperandName }
+func (LiteralString) OperandType() OperandType     { return OperandLiteralString }
+func (HexadecimalString) OperandType() OperandType { return OperandHexadecimalString }
+func (Array) OperandType() OperandType             { return OperandArray }
+func (Dict) OperandType() OperandType              { return OperandDict }
+func (Boolean) OperandType() OperandType           { return OperandBoolean }
+func (Null) OperandType() OperandType              { return OperandNull }
+func (InlineImage) OperandType() OperandType       { return OperandInlineImage }
+
+// Unescape resolves escape sequences as described in 7.3.4.2 - Literal Strings.
+func (s LiteralString) Unescape() []byte {
+	return s.unescape(true)
+}
+
+// UnescapeBinary is Unescape which keeps end-of-line markers as they are, escaped ones included - for strings holding
+// binary data, e.g. UTF-16 text of TextDocument, where a \r byte is part of a character.
+func (s LiteralString) UnescapeBinary() []byte {
+	return s.unescape(false)
+}
+
+func (s LiteralString) unescape(normalizeEOL bool) []byte {
+	ret := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\r' && normalizeEOL {
+			// an end-of-line marker appearing within a literal string is treated as a byte value of (0Ah)
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+			ret = append(ret, '\n')
+			continue
+		}
+		if c != '\\' || i+1 == len(s) {
+			ret = append(ret, c)
+			continue
+		}
+		i++
+		switch c = s[i]; c {
+		case 'n':
+			ret = append(ret, '\n')
+		case 'r':
+			ret = append(ret, '\r')
+		case 't':
+			ret = append(ret, '\t')
+		case 'b':
+			ret = append(ret, '\b')
+		case 'f':
+			ret = append(ret, '\f')
+		case '\r', '\n':
+			if !normalizeEOL {
+				// binary data keeps the escaped byte
+				ret = append(ret, c)
+			} else if c == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+				// backslash followed by EOL is a line continuation
+				i++
+			}
+		default:
+			if !isOctal(c) {
+				// backslash is ignored if not followed by any of the above
+				ret = append(ret, c)
+				continue
+			}
+			val := c - '0'
+			for n := 1; n < 3 && i+1 < len(s) && isOctal(s[i+1]); n++ {
+				i++
+				val = val*8 + s[i] - '0'
+			}
+			ret = append(ret, val)
+		}
+	}
+	return ret
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+type jsonOperand struct {
+	Type  OperandType `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+func (o Number) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonOperand{OperandNumber, float64(o)})
+}
+
+func (o Name) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonOperand{OperandName, string(o)})
+}
+
+func (o LiteralString) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonOperand{OperandLiteralString, []byte(o)})
+}
+
+func (o HexadecimalString) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonOperand{OperandHexadecimalString, string(o)})
+}
+
+func (o Array) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonOperand{OperandArray, []Operand(o)})
+}
+
+func (o Dict) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonOperand{OperandDict, map[string]Operand(o)})
+}
+
+func (o Boolean) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonOperand{OperandBoolean, bool(o)})
+}
+
+func (o Null) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonOperand{OperandNull, nil})
+}
+
+func (o InlineImage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonOperand{OperandInlineImage, struct {
+		Dict Dict
+		Data []byte
+	}{o.Dict, o.Data}})
+}
@@ -0,0 +1,242 @@
+// https://web.archive.org/web/20220226063926/https://www.adobe.com/content/dam/acom/en/devnet/pdf/pdfs/PDF32000_2008.pdf
+// 7.8.2 Content Streams
+
+package contents
+
+import (
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// Operator is a single content stream instruction together with operands preceding it.
+type Operator struct {
+	Name string
+	Args []Operand
+}
+
+// Parser tokenizes content stream into operators, one by one - API mimics bufio.Scanner
+type Parser struct {
+	lx  lexer
+	op  Operator
+	err error
+}
+
+func NewParser(data []byte) *Parser {
+	return &Parser{lx: lexer{data: data}}
+}
+
+// Scan advances the Parser to the next operator, which will then be available through the Operator method.
+func (p *Parser) Scan() bool {
+	if p.err != nil {
+		return false
+	}
+	var args []Operand
+	for {
+		p.lx.skipWhitespace()
+		if p.lx.eof() {
+			// dangling operands are dropped, same as in src/syntax/parser.ts
+			return false
+		}
+		operand, keyword, err := p.object()
+		if err != nil {
+			p.err = err
+			return false
+		}
+		if operand != nil {
+			args = append(args, operand)
+			continue
+		}
+		if keyword == "BI" {
+			img, err := p.inlineImage()
+			if err != nil {
+				p.err = errors.WithMessage(err, "while parsing inline image")
+				return false
+			}
+			args = []Operand{img}
+		}
+		p.op = Operator{Name: keyword, Args: args}
+		return true
+	}
+}
+
+// Operator returns the most recent operator generated by a call to Scan.
+func (p *Parser) Operator() Operator {
+	return p.op
+}
+
+// Err returns the first error that was encountered by the Parser.
+func (p *Parser) Err() error {
+	return p.err
+}
+
+// object reads either a single operand or a keyword (operator)
+func (p *Parser) object() (Operand, string, error) {
+	lx := &p.lx
+	switch c := lx.peek(); c {
+	case '/':
+		name, err := lx.name()
+		return name, "", err
+	case '(':
+		str, err := lx.literalString()
+		return str, "", err
+	case '<':
+		if lx.pos+1 < len(lx.data) && lx.data[lx.pos+1] == '<' {
+			lx.pos += 2
+			dict, err := p.dict(">>")
+			return dict, "", err
+		}
+		str, err := lx.hexadecimalString()
+		return str, "", err
+	case '[':
+		lx.pos++
+		arr, err := p.array()
+		return arr, "", err
+	case ')', '>', ']', '{', '}':
+		return nil, "", lx.errorf("unexpected delimiter '%c'", c)
+	}
+	raw := lx.regular()
+	switch str := string(raw); str {
+	case "true":
+		return Boolean(true), "", nil
+	case "false":
+		return Boolean(false), "", nil
+	case "null":
+		return Null{}, "", nil
+	default:
+		if c := raw[0]; (c >= '0' && c <= '9') || c == '+' || c == '-' || c == '.' {
+			num, err := strconv.ParseFloat(str, 64)
+			if err != nil {
+				return nil, "", lx.errorf("malformed number '%s'", str)
+			}
+			return Number(num), "", nil
+		}
+		return nil, str, nil
+	}
+}
+
+func (p *Parser) operand() (Operand, error) {
+	start := p.lx.pos
+	operand, keyword, err := p.object()
+	if err == nil && operand == nil {
+		p.lx.pos = start
+		err = p.lx.errorf("unexpected operator '%s'", keyword)
+	}
+	return operand, err
+}
+
+func (p *Parser) array() (Array, error) {
+	arr := Array{}
+	for {
+		p.lx.skipWhitespace()
+		if p.lx.eof() {
+			return nil, p.lx.errorf("unterminated array")
+		}
+		if p.lx.peek() == ']' {
+			p.lx.pos++
+			return arr, nil
+		}
+		val, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, val)
+	}
+}
+
+// dict reads key-value pairs up to terminator - ">>" for regular dicts, "ID" for inline images, "" for the end of data
+func (p *Parser) dict(terminator string) (Dict, error) {
+	dict := Dict{}
+	for {
+		p.lx.skipWhitespace()
+		if p.lx.eof() {
+			if terminator == "" {
+				return dict, nil
+			}
+			return nil, p.lx.errorf("unterminated dict")
+		}
+		if p.atTerminator(terminator) {
+			p.lx.pos += len(terminator)
+			return dict, nil
+		}
+		key, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(Name)
+		if !ok {
+			return nil, p.lx.errorf("dict key is not a Name, is '%s'", key.OperandType())
+		}
+		p.lx.skipWhitespace()
+		if p.lx.eof() {
+			return nil, p.lx.errorf("missing value for key '%s'", name)
+		}
+		val, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		dict[string(name)] = val
+	}
+}
+
+func (p *Parser) atTerminator(terminator string) bool {
+	lx := &p.lx
+	switch terminator {
+	case "":
+		return false
+	case ">>":
+		return lx.pos+1 < len(lx.data) && lx.data[lx.pos] == '>' && lx.data[lx.pos+1] == '>'
+	default:
+		return lx.keyword(terminator)
+	}
+}
+
+// 8.9.7 Inline Images
+func (p *Parser) inlineImage() (InlineImage, error) {
+	lx := &p.lx
+	dict, err := p.dict("ID")
+	if err != nil {
+		return InlineImage{}, err
+	}
+	// a single white-space character follows ID, then the data itself
+	lx.pos++
+	start := lx.pos
+	for ; lx.pos+1 < len(lx.data); lx.pos++ {
+		if lx.data[lx.pos] == 'E' && isWhitespace(lx.data[lx.pos-1]) && lx.keyword("EI") {
+			end := lx.pos - 1
+			if end < start {
+				end = start
+			}
+			data := lx.data[start:end]
+			lx.pos += 2
+			return InlineImage{Dict: dict, Data: data}, nil
+		}
+	}
+	return InlineImage{}, lx.errorf("missing EI")
+}
+
+// ParseOperators returns all operators found in data
+func ParseOperators(data []byte) ([]Operator, error) {
+	var ops []Operator
+	p := NewParser(data)
+	for p.Scan() {
+		ops = append(ops, p.Operator())
+	}
+	return ops, p.Err()
+}
+
+// ParseDict reads data as key-value pairs of a dict without enclosing << >> - same as parseDict in src/syntax/parser.ts.
+// Used for AI11TextDocument, which shares the syntax with content streams.
+func ParseDict(data []byte) (Dict, error) {
+	p := NewParser(data)
+	return p.dict("")
+}
+
+// ParseStreamDict decodes content stream and returns all operators found within
+func ParseStreamDict(sd *pdfcpu.StreamDict) ([]Operator, error) {
+	if err := sd.Decode(); err != nil {
+		return nil, errors.Wrapf(err, "while parsing dict contents")
+	}
+	return ParseOperators(sd.Content)
+}
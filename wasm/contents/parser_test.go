@@ -0,0 +1,112 @@
+package contents
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOperators(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected []Operator
+	}{
+		{"numbers", "1 -2.5 +.5 re", []Operator{{"re", []Operand{Number(1), Number(-2.5), Number(0.5)}}}},
+		{"no operands", "q Q", []Operator{{"q", nil}, {"Q", nil}}},
+		{"name escape", "/Pattern#20A cs", []Operator{{"cs", []Operand{Name("Pattern A")}}}},
+		{"nested literal string", `(a(b)\)c) Tj`, []Operator{{"Tj", []Operand{LiteralString(`a(b)\)c`)}}}},
+		{"hexadecimal string", "<48 65\n6c> Tj", []Operator{{"Tj", []Operand{HexadecimalString("48656c")}}}},
+		{"array", "[(a) -120 (b)] TJ", []Operator{{"TJ", []Operand{Array{LiteralString("a"), Number(-120), LiteralString("b")}}}}},
+		{"dict", "/Span << /ActualText (x) /MCID 0 >> BDC", []Operator{
+			{"BDC", []Operand{Name("Span"), Dict{"ActualText": LiteralString("x"), "MCID": Number(0)}}},
+		}},
+		{"keywords", "[true false null] d0", []Operator{{"d0", []Operand{Array{Boolean(true), Boolean(false), Null{}}}}}},
+		{"comments", "% comment\r0 g % another\n1 G", []Operator{{"g", []Operand{Number(0)}}, {"G", []Operand{Number(1)}}}},
+		{"quote operators", "(a) ' 1 2 (b) \"", []Operator{
+			{"'", []Operand{LiteralString("a")}},
+			{"\"", []Operand{Number(1), Number(2), LiteralString("b")}},
+		}},
+		{"inline image", "BI /W 1 /H 1 ID \x00\xff EI Q", []Operator{
+			{"BI", []Operand{InlineImage{Dict: Dict{"W": Number(1), "H": Number(1)}, Data: []byte("\x00\xff")}}},
+			{"Q", nil},
+		}},
+		{"dangling operands", "1 0 0 RG 5", []Operator{{"RG", []Operand{Number(1), Number(0), Number(0)}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := ParseOperators([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ops, tt.expected) {
+				t.Errorf("got %#v, expected %#v", ops, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseOperatorsErrors(t *testing.T) {
+	for _, data := range []string{
+		"(unterminated Tj",
+		"<48 Tj",
+		"[1 2 d",
+		"<< /A 1 BDC",
+		"<< 1 2 >> BDC",
+		"1.2.3 w",
+		"] TJ",
+		"[1 re] f",
+		"BI /W 1 ID data",
+	} {
+		if ops, err := ParseOperators([]byte(data)); err == nil {
+			t.Errorf("%q parsed as %v", data, ops)
+		}
+	}
+}
+
+func TestParseDict(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected Dict
+	}{
+		{"empty", " \r\n", Dict{}},
+		{"flat", "/0 1 /1 (a) /2 true", Dict{"0": Number(1), "1": LiteralString("a"), "2": Boolean(true)}},
+		{"nested", "/0 << /1 [ << /0 1 >> ] >>", Dict{"0": Dict{"1": Array{Dict{"0": Number(1)}}}}},
+		{"trailing comment", "/0 /Name % end", Dict{"0": Name("Name")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dict, err := ParseDict([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(dict, tt.expected) {
+				t.Errorf("got %#v, expected %#v", dict, tt.expected)
+			}
+		})
+	}
+	for _, data := range []string{"/0", "/0 1 /1", "0 1", "/0 << /1 2", "/0 op"} {
+		if dict, err := ParseDict([]byte(data)); err == nil {
+			t.Errorf("%q parsed as %v", data, dict)
+		}
+	}
+}
+
+func TestLiteralStringUnescape(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{`a\(b\)`, "a(b)"},
+		{`\n\r\t\b\f`, "\n\r\t\b\f"},
+		{`\101\0611\7`, "A11\a"},
+		{"a\\\nb", "ab"},
+		{"a\r\nb\rc", "a\nb\nc"},
+		{`\\\q`, `\q`},
+	}
+	for _, tt := range tests {
+		if got := string(LiteralString(tt.raw).Unescape()); got != tt.expected {
+			t.Errorf("%q got %q, expected %q", tt.raw, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package contents
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pathBuilder consumes a single path object - construction operators followed by optional clipping and a painting operator.
+// See 8.5 Path Construction and Painting
+type pathBuilder struct {
+	path         Path
+	clippingPath *Path
+	currentPath  []PathPoint
+	currentPoint []float64
+
+	strictPopplerCompat bool
+}
+
+func newPathBuilder(graphicsState GraphicsState, strictPopplerCompat bool) *pathBuilder {
+	return &pathBuilder{
+		path: Path{
+			Type:          "Path",
+			GraphicsState: graphicsState,
+			FillRule:      FillRuleNonZeroWindingNumber,
+		},
+		strictPopplerCompat: strictPopplerCompat,
+	}
+}
+
+func (pb *pathBuilder) finishCurrentPath(closed bool) {
+	if len(pb.currentPath) > 0 {
+		pb.path.Subpaths = append(pb.path.Subpaths, Subpath{Type: "Path", Points: pb.currentPath, Closed: closed})
+		pb.currentPath = nil
+	}
+}
+
+func (pb *pathBuilder) pushPoint(kind string, coords []float64) {
+	pb.currentPath = append(pb.currentPath, PathPoint{Type: kind, Coords: coords})
+	pb.currentPoint = coords[len(coords)-2:]
+}
+
+// apply returns true once path object is finished, painted (path != nil) or not
+func (pb *pathBuilder) apply(op Operator) (done bool, path *Path, err error) {
+	switch op.Name {
+	// 8.5.2 Path Construction Operators
+	case "m", "l", "c", "v", "y", "re":
+		coords, err := decodeNumbers(op.Args)
+		if err != nil {
+			return false, nil, errors.WithMessagef(err, "in '%s'", op.Name)
+		}
+		return false, nil, pb.construct(op.Name, coords)
+	case "h":
+		pb.finishCurrentPath(true)
+		return false, nil, nil
+
+	// 8.5.3 Path-Painting Operators
+	case "S", "s":
+		pb.finishCurrentPath(isLowerCase(op.Name))
+		path := pb.path
+		path.Stroke = true
+		return true, &path, nil
+	case "F", "f":
+		pb.finishCurrentPath(true)
+		path := pb.path
+		path.Fill = true
+		return true, &path, nil
+	case "f*":
+		pb.finishCurrentPath(true)
+		path := pb.path
+		path.Fill = true
+		path.FillRule = FillRuleEvenOdd
+		return true, &path, nil
+	case "B", "b":
+		pb.finishCurrentPath(isLowerCase(op.Name))
+		path := pb.path
+		path.Fill = true
+		path.Stroke = true
+		return true, &path, nil
+	case "B*", "b*":
+		pb.finishCurrentPath(isLowerCase(op.Name))
+		path := pb.path
+		path.Fill = true
+		path.Stroke = true
+		path.FillRule = FillRuleEvenOdd
+		return true, &path, nil
+	case "n":
+		return true, nil, nil
+
+	// 8.5.4 Clipping Path Operators
+	case "W", "W*":
+		if op.Name == "W*" {
+			pb.path.FillRule = FillRuleEvenOdd
+		}
+		pb.finishCurrentPath(true)
+		if pb.strictPopplerCompat {
+			pb.path.GraphicsState.ClippingPath = nil
+		}
+		clippingPath := pb.path
+		pb.clippingPath = &clippingPath
+		return false, nil, nil
+	}
+	return false, nil, errors.Errorf("unhandled path-builder operator: %s", op.Name)
+}
+
+func (pb *pathBuilder) construct(name string, coords []float64) error {
+	expected := pathConstructionOperands[name]
+	if len(coords) != expected {
+		return errors.Errorf("'%s' expects %d operands, got %d", name, expected, len(coords))
+	}
+	switch name {
+	case "m":
+		pb.finishCurrentPath(false)
+		pb.pushPoint("Move", coords)
+	case "l":
+		pb.pushPoint("Line", coords)
+	case "c":
+		// x1 y1 x2 y2 x3 y3
+		pb.pushPoint("Curve", coords)
+	case "v":
+		// x2 y2 x3 y3 - current point is the first control point
+		if pb.currentPoint == nil {
+			return errors.New("currentPoint is undefined")
+		}
+		pb.pushPoint("Curve", append(append([]float64{}, pb.currentPoint...), coords...))
+	case "y":
+		// x1 y1 x3 y3 - final point is the second control point
+		pb.pushPoint("Curve", append(coords, coords[2:]...))
+	case "re":
+		pb.path.Subpaths = append(pb.path.Subpaths, Subpath{Type: "Rect", Coords: coords})
+	}
+	return nil
+}
+
+func isLowerCase(str string) bool {
+	return strings.ToLower(str) == str
+}
@@ -0,0 +1,276 @@
+package contents
+
+import (
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// Reducer folds content stream operators into a tree of nodes with resolved graphics state - Go counterpart of src/contents/reducer.ts
+type Reducer struct {
+	xRefTable           *pdfcpu.XRefTable
+	resources           pdfcpu.Dict
+	strictPopplerCompat bool
+
+	context       []*MarkedContext // stack, top is the last element
+	graphics      []GraphicsState  // stack, top is the last element
+	textBuilder   *textBuilder
+	compatibility int // depth of BX/EX sections, in which unknown operators are ignored
+}
+
+// NewReducer creates Reducer for content stream using given resources, xRefTable is used to dereference them
+func NewReducer(xRefTable *pdfcpu.XRefTable, resources pdfcpu.Dict, strictPopplerCompat bool) *Reducer {
+	return &Reducer{
+		xRefTable:           xRefTable,
+		resources:           resources,
+		strictPopplerCompat: strictPopplerCompat,
+		context:             []*MarkedContext{{Type: "MarkedContext"}},
+		graphics:            []GraphicsState{NewGraphicsState()},
+	}
+}
+
+// Run applies all operators and returns top-level nodes. Marked contexts left open at the end are dropped, same as in src/contents/index.ts
+func (r *Reducer) Run(ops []Operator) ([]Node, error) {
+	for idx := 0; idx < len(ops); idx++ {
+		op := ops[idx]
+		if _, ok := pathConstructionOperands[op.Name]; ok {
+			consumed, err := r.applyPathObjectOps(ops[idx:])
+			if err != nil {
+				return nil, errors.WithMessagef(err, "in path object starting at operator #%d", idx)
+			}
+			idx += consumed - 1
+			continue
+		}
+		if err := r.apply(op); err != nil {
+			return nil, errors.WithMessagef(err, "at operator #%d '%s'", idx, op.Name)
+		}
+	}
+	return r.context[0].Kids, nil
+}
+
+// ReduceStreamDict parses content stream and reduces it using resources from its own dict (as in Form XObjects)
+func ReduceStreamDict(xRefTable *pdfcpu.XRefTable, sd *pdfcpu.StreamDict, strictPopplerCompat bool) ([]Node, error) {
+	ops, err := ParseStreamDict(sd)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := xRefTable.DereferenceDict(sd.Dict["Resources"])
+	if err != nil {
+		return nil, errors.WithMessage(err, "while dereferencing Resources")
+	}
+	return NewReducer(xRefTable, resources, strictPopplerCompat).Run(ops)
+}
+
+var pathConstructionOperands = map[string]int{"m": 2, "l": 2, "c": 6, "v": 4, "y": 4, "re": 4, "h": 0}
+
+func (r *Reducer) currentGraphics() *GraphicsState {
+	return &r.graphics[len(r.graphics)-1]
+}
+
+func (r *Reducer) pushKid(kid Node) {
+	top := r.context[len(r.context)-1]
+	top.Kids = append(top.Kids, kid)
+}
+
+func (r *Reducer) deref(obj pdfcpu.Object) pdfcpu.Object {
+	if r.xRefTable == nil {
+		return obj
+	}
+	ret, err := r.xRefTable.Dereference(obj)
+	if err != nil {
+		return nil
+	}
+	return ret
+}
+
+// resource looks up name in given category (e.g. ColorSpace) of resource dictionary
+func (r *Reducer) resource(category, name string) pdfcpu.Object {
+	dict, ok := r.deref(r.resources[category]).(pdfcpu.Dict)
+	if !ok {
+		return nil
+	}
+	return r.deref(dict[name])
+}
+
+// Path object only allows path construction operators - so we can safely handle it in a separate loop
+func (r *Reducer) applyPathObjectOps(ops []Operator) (int, error) {
+	pb := newPathBuilder(*r.currentGraphics(), r.strictPopplerCompat)
+	for idx, op := range ops {
+		done, path, err := pb.apply(op)
+		if err != nil {
+			return idx, err
+		}
+		if !done {
+			continue
+		}
+		if path != nil {
+			r.pushKid(path)
+		}
+		if pb.clippingPath != nil {
+			g := r.currentGraphics()
+			// copy, so graphics states saved with q are left intact
+			g.ClippingPath = append(append([]*Path{}, g.ClippingPath...), pb.clippingPath)
+		}
+		return idx + 1, nil
+	}
+	return len(ops), errors.New("path object is not finished with painting operator")
+}
+
+func (r *Reducer) apply(op Operator) (err error) {
+	switch op.Name {
+	case "BX":
+		r.compatibility++
+		return nil
+	case "EX":
+		if r.compatibility > 0 {
+			r.compatibility--
+		}
+		return nil
+	// 14.6 Marked Content
+	case "BDC", "BMC":
+		return r.pushMarkedContext(op.Args)
+	case "EMC":
+		return r.popMarkedContext()
+	case "MP", "DP":
+		return nil
+	// 8.4.4 Graphics State Operators
+	case "q", "Q", "cm", "w", "J", "j", "M", "d", "ri", "i", "gs":
+		return r.applyGraphicStateOperators(op)
+	// 8.8 External Objects
+	case "Do":
+		name, err := decodeName(op.Args)
+		if err != nil {
+			return err
+		}
+		r.pushKid(&XObject{Type: "XObject", Name: name, GraphicsState: *r.currentGraphics()})
+		return nil
+	// 8.7.4.2 Shading Operator
+	case "sh":
+		name, err := decodeName(op.Args)
+		if err != nil {
+			return err
+		}
+		r.pushKid(&Shading{Type: "Shading", Name: name, GraphicsState: *r.currentGraphics()})
+		return nil
+	// 8.9.7 Inline Images
+	case "BI":
+		if len(op.Args) == 1 {
+			if img, ok := op.Args[0].(InlineImage); ok {
+				r.pushKid(&InlineImageNode{Type: "InlineImage", Image: img, GraphicsState: *r.currentGraphics()})
+				return nil
+			}
+		}
+		return errors.New("BI without inline image")
+	// 9.6.5 Type 3 fonts
+	case "d0", "d1":
+		return nil
+	case "BT", "ET", "Td", "TD", "Tm", "T*", "Tj", "'", "\"", "TJ":
+		return r.applyTextOperators(op)
+	case "Tc", "Tw", "Tz", "TL", "Tf", "Tr", "Ts":
+		return r.applyTextStateOperators(op)
+	case "CS", "cs", "SC", "SCN", "sc", "scn", "G", "g", "RG", "rg", "K", "k":
+		return r.applyColourOperators(op)
+	}
+	if r.compatibility > 0 {
+		return nil
+	}
+	return errors.Errorf("unhandled operator %s", op.Name)
+}
+
+func (r *Reducer) pushMarkedContext(args []Operand) error {
+	if len(args) == 0 {
+		return errors.New("marked context without tag")
+	}
+	tag, ok := args[0].(Name)
+	if !ok {
+		return errors.Errorf("bogus operand '%s' as marked context tag", args[0].OperandType())
+	}
+	mc := &MarkedContext{Type: "MarkedContext", Tag: string(tag)}
+	if len(args) > 1 {
+		// inline property dicts are not kept, same as in src/contents
+		if props, ok := args[1].(Name); ok {
+			mc.Properties = string(props)
+		}
+	}
+	r.context = append(r.context, mc)
+	return nil
+}
+
+func (r *Reducer) popMarkedContext() error {
+	if len(r.context) == 1 {
+		return errors.New("Marked Context pop without respective push")
+	}
+	top := r.context[len(r.context)-1]
+	r.context = r.context[:len(r.context)-1]
+	r.pushKid(top)
+	return nil
+}
+
+func (r *Reducer) decodeSpecifiedParameters(args []Operand) (interface{}, error) {
+	name, err := decodeName(args)
+	if err != nil || r.strictPopplerCompat {
+		return name, err
+	}
+	if val := r.resource("ExtGState", name); val != nil {
+		return val, nil
+	}
+	return name, nil
+}
+
+func (r *Reducer) applyGraphicStateOperators(op Operator) (err error) {
+	g := r.currentGraphics()
+	switch op.Name {
+	case "q":
+		r.graphics = append(r.graphics, *g)
+	case "Q":
+		if len(r.graphics) == 1 {
+			return errors.New("Empty graphics stack")
+		}
+		r.graphics = r.graphics[:len(r.graphics)-1]
+	case "cm":
+		g.CTM, err = decodeNumbers(op.Args)
+	case "w":
+		g.LineWidth, err = decodeNumber(op.Args)
+	case "J":
+		g.LineCap, err = decodeNumber(op.Args)
+	case "j":
+		g.LineJoin, err = decodeNumber(op.Args)
+	case "M":
+		g.MiterLimit, err = decodeNumber(op.Args)
+	case "d":
+		g.DashPattern, err = decodeDashPattern(op.Args)
+	case "ri":
+		g.RenderingIntent, err = decodeName(op.Args)
+	case "i":
+		g.Flatness, err = decodeNumber(op.Args)
+	case "gs":
+		g.SpecifiedParameters, err = r.decodeSpecifiedParameters(op.Args)
+	}
+	return
+}
+
+func (r *Reducer) applyTextStateOperators(op Operator) (err error) {
+	g := r.currentGraphics()
+	switch op.Name {
+	case "Tw":
+		g.TextWordSpace, err = decodeNumber(op.Args)
+	case "Tc":
+		g.TextCharSpace, err = decodeNumber(op.Args)
+	case "Tz":
+		g.TextScale, err = decodeNumber(op.Args)
+	case "TL":
+		g.TextLeading, err = decodeNumber(op.Args)
+	case "Tf":
+		if len(op.Args) != 2 {
+			return errors.Errorf("Tf expects 2 operands, got %d", len(op.Args))
+		}
+		if g.TextFont, err = decodeName(op.Args[:1]); err != nil {
+			return err
+		}
+		g.TextFontSize, err = decodeNumber(op.Args[1:])
+	case "Tr":
+		g.TextRender, err = decodeNumber(op.Args)
+	case "Ts":
+		g.TextRise, err = decodeNumber(op.Args)
+	}
+	return
+}
@@ -0,0 +1,146 @@
+package contents
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+func reduce(content string, resources pdfcpu.Dict) ([]Node, error) {
+	ops, err := ParseOperators([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	return NewReducer(nil, resources, false).Run(ops)
+}
+
+func TestColourOperators(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		colorSpace interface{}
+		color      []interface{}
+		stroking   bool
+	}{
+		{"gray", "0.5 g", "DeviceGray", []interface{}{0.5}, false},
+		{"rgb", "1 0 0.5 RG", "DeviceRGB", []interface{}{1.0, 0.0, 0.5}, true},
+		{"cmyk", "0 0 0 1 k", "DeviceCMYK", []interface{}{0.0, 0.0, 0.0, 1.0}, false},
+		{"colour space", "/DeviceRGB CS 0.1 0.2 0.3 SC", pdfcpu.Name("DeviceRGB"), []interface{}{0.1, 0.2, 0.3}, true},
+		{"pattern", "/Pattern cs /P0 scn", pdfcpu.Name("Pattern"), []interface{}{"P0"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := reduce(tt.content+" 0 0 10 10 re B", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(nodes) != 1 {
+				t.Fatalf("got %d nodes, expected 1", len(nodes))
+			}
+			g := nodes[0].(*Path).GraphicsState
+			colorSpace, color := g.ColorSpaceNonStroking, g.ColorNonStroking
+			if tt.stroking {
+				colorSpace, color = g.ColorSpaceStroking, g.ColorStroking
+			}
+			if colorSpace != tt.colorSpace || !reflect.DeepEqual(color, tt.color) {
+				t.Errorf("got %v %v, expected %v %v", colorSpace, color, tt.colorSpace, tt.color)
+			}
+		})
+	}
+}
+
+func TestDeviceColourOperandCount(t *testing.T) {
+	for _, content := range []string{"0.5 0.5 G", "g", "1 0 rg", "1 0 0 0 RG", "0 0 1 K", "0 0 0 0 0 k"} {
+		if _, err := reduce(content, nil); err == nil {
+			t.Errorf("%q reduced without error", content)
+		}
+	}
+}
+
+func TestReducePaths(t *testing.T) {
+	nodes, err := reduce("10 20 m 30 40 l 1 2 3 4 5 6 c h S 0 0 5 5 re f* 0 0 1 1 re B", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("got %d nodes, expected 3", len(nodes))
+	}
+	stroked := nodes[0].(*Path)
+	expected := []Subpath{{Type: "Path", Closed: true, Points: []PathPoint{
+		{Type: "Move", Coords: []float64{10, 20}},
+		{Type: "Line", Coords: []float64{30, 40}},
+		{Type: "Curve", Coords: []float64{1, 2, 3, 4, 5, 6}},
+	}}}
+	if !reflect.DeepEqual(stroked.Subpaths, expected) || !stroked.Stroke || stroked.Fill {
+		t.Errorf("stroked path got %+v", stroked)
+	}
+	filled := nodes[1].(*Path)
+	if !reflect.DeepEqual(filled.Subpaths, []Subpath{{Type: "Rect", Coords: []float64{0, 0, 5, 5}}}) ||
+		!filled.Fill || filled.Stroke || filled.FillRule != FillRuleEvenOdd {
+		t.Errorf("filled path got %+v", filled)
+	}
+	both := nodes[2].(*Path)
+	if !both.Fill || !both.Stroke || both.FillRule != FillRuleNonZeroWindingNumber {
+		t.Errorf("filled and stroked path got %+v", both)
+	}
+}
+
+func TestReduceGraphicsState(t *testing.T) {
+	resources := pdfcpu.Dict{"ExtGState": pdfcpu.Dict{"GS0": pdfcpu.Dict{"CA": pdfcpu.Float(0.5)}}}
+	nodes, err := reduce("q 2 w 1 0 0 1 5 5 cm /GS0 gs 0 0 10 10 re W n 0 0 1 1 re f Q 0 0 1 1 re f", resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("got %d nodes, expected 2 - clipping path is not painted", len(nodes))
+	}
+	inner := nodes[0].(*Path).GraphicsState
+	if inner.LineWidth != 2 || !reflect.DeepEqual(inner.CTM, []float64{1, 0, 0, 1, 5, 5}) || len(inner.ClippingPath) != 1 {
+		t.Errorf("inner state got %+v", inner)
+	}
+	if !reflect.DeepEqual(inner.SpecifiedParameters, resources["ExtGState"].(pdfcpu.Dict)["GS0"]) {
+		t.Errorf("ExtGState got %v", inner.SpecifiedParameters)
+	}
+	outer := nodes[1].(*Path).GraphicsState
+	if outer.LineWidth != 1 || outer.CTM != nil || outer.ClippingPath != nil || outer.SpecifiedParameters != nil {
+		t.Errorf("restored state got %+v", outer)
+	}
+}
+
+func TestReduceMarkedContent(t *testing.T) {
+	nodes, err := reduce("/OC /MC0 BDC /Fm0 Do /P BMC /Sh0 sh EMC EMC BT /F1 12 Tf (Hi) Tj ET", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("got %d nodes, expected 2", len(nodes))
+	}
+	layer := nodes[0].(*MarkedContext)
+	if layer.Tag != "OC" || layer.Properties != "MC0" || len(layer.Kids) != 2 {
+		t.Fatalf("marked context got %+v", layer)
+	}
+	if xObject := layer.Kids[0].(*XObject); xObject.Name != "Fm0" {
+		t.Errorf("XObject got %+v", xObject)
+	}
+	nested := layer.Kids[1].(*MarkedContext)
+	if nested.Tag != "P" || len(nested.Kids) != 1 || nested.Kids[0].(*Shading).Name != "Sh0" {
+		t.Errorf("nested marked context got %+v", nested)
+	}
+	group := nodes[1].(*TextGroup)
+	if len(group.Texts) != 1 || !reflect.DeepEqual(group.Texts[0].Text, LiteralString("Hi")) ||
+		group.Texts[0].GraphicsState.TextFont != "F1" || group.Texts[0].GraphicsState.TextFontSize != 12 {
+		t.Errorf("text group got %+v", group)
+	}
+}
+
+func TestReduceErrors(t *testing.T) {
+	for _, content := range []string{"Q", "EMC", "0 0 1 1 re", "0 0 1 1 re Tj", "unknown", "12 Tf", "/F1 Tf", "Do", "1 /a w"} {
+		if _, err := reduce(content, nil); err == nil {
+			t.Errorf("%q reduced without error", content)
+		}
+	}
+	if _, err := reduce("BX unknown EX 0 g", nil); err != nil {
+		t.Errorf("unknown operator within BX/EX got %v", err)
+	}
+}
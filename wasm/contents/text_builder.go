@@ -0,0 +1,139 @@
+package contents
+
+import (
+	"github.com/pkg/errors"
+)
+
+type textBuilder struct {
+	textMatrix [6]float64
+	text       *TextGroup
+}
+
+func newTextBuilder() *textBuilder {
+	return &textBuilder{textMatrix: [6]float64{1, 0, 0, 1, 0, 0}}
+}
+
+// translate moves text matrix origin, same as mat2d.translate from gl-matrix
+func (tb *textBuilder) translate(x, y float64) {
+	m := &tb.textMatrix
+	m[4] += m[0]*x + m[2]*y
+	m[5] += m[1]*x + m[3]*y
+}
+
+func (tb *textBuilder) showText(graphicsState GraphicsState, text Operand) {
+	matrix := tb.textMatrix[:]
+	entry := Text{
+		GraphicsState:  graphicsState,
+		TextMatrix:     append([]float64{}, matrix...),
+		TextLineMatrix: append([]float64{}, matrix...), // TODO: do we need separate a line matrix?
+		Text:           text,
+	}
+	if tb.text == nil {
+		tb.text = &TextGroup{Type: "TextGroup"}
+	}
+	tb.text.Texts = append(tb.text.Texts, entry)
+}
+
+func (r *Reducer) finishTextGroup() {
+	if r.textBuilder != nil && r.textBuilder.text != nil {
+		r.pushKid(r.textBuilder.text)
+		r.textBuilder.text = nil
+	}
+}
+
+func (r *Reducer) withTextBuilder() (*textBuilder, error) {
+	if r.textBuilder == nil {
+		return nil, errors.New("Empty text builder")
+	}
+	return r.textBuilder, nil
+}
+
+// 9.4 Text Objects
+func (r *Reducer) applyTextOperators(op Operator) error {
+	switch op.Name {
+	case "BT":
+		r.textBuilder = newTextBuilder()
+		return nil
+	case "ET":
+		r.finishTextGroup()
+		r.textBuilder = nil
+		return nil
+	}
+
+	tb, err := r.withTextBuilder()
+	if err != nil {
+		return err
+	}
+	g := r.currentGraphics()
+	switch op.Name {
+	// 9.4.2 Text-Positioning Operators
+	case "TD":
+		if len(op.Args) != 2 {
+			return errors.Errorf("TD expects 2 operands, got %d", len(op.Args))
+		}
+		y, err := decodeNumber(op.Args[1:])
+		if err != nil {
+			return err
+		}
+		g.TextLeading = -y
+		return r.applyTextOperators(Operator{Name: "Td", Args: op.Args})
+	case "Tm":
+		m, err := decodeNumbers(op.Args)
+		if err != nil {
+			return err
+		}
+		if len(m) != 6 {
+			return errors.Errorf("Tm expects 6 operands, got %d", len(m))
+		}
+		copy(tb.textMatrix[:], m)
+		r.finishTextGroup()
+	case "Td":
+		v, err := decodeNumbers(op.Args)
+		if err != nil {
+			return err
+		}
+		if len(v) != 2 {
+			return errors.Errorf("Td expects 2 operands, got %d", len(v))
+		}
+		tb.translate(v[0], v[1])
+		r.finishTextGroup()
+	// NOTE: I'm not sure if these should modify textMatrix. poppler doesn't and yet there are differences in some cases.
+	case "T*":
+		tb.translate(0, -g.TextLeading)
+
+	// 9.4.3 Text-Showing Operators
+	case "Tj":
+		if g.TextFont == "" {
+			return errors.New("TextFont is undefined during Tj")
+		}
+		if len(op.Args) != 1 {
+			return errors.Errorf("Tj expects 1 operand, got %d", len(op.Args))
+		}
+		tb.showText(*g, op.Args[0])
+	case "'":
+		if err := r.applyTextOperators(Operator{Name: "T*"}); err != nil {
+			return err
+		}
+		return r.applyTextOperators(Operator{Name: "Tj", Args: op.Args})
+	case "\"":
+		if len(op.Args) != 3 {
+			return errors.Errorf("\" expects 3 operands, got %d", len(op.Args))
+		}
+		if err := r.applyTextStateOperators(Operator{Name: "Tw", Args: op.Args[0:1]}); err != nil {
+			return err
+		}
+		if err := r.applyTextStateOperators(Operator{Name: "Tc", Args: op.Args[1:2]}); err != nil {
+			return err
+		}
+		return r.applyTextOperators(Operator{Name: "'", Args: op.Args[2:]})
+	case "TJ":
+		if g.TextFont == "" {
+			return errors.New("TextFont is undefined during TJ")
+		}
+		if len(op.Args) != 1 || op.Args[0].OperandType() != OperandArray {
+			return errors.New("TJ argument is not an array")
+		}
+		tb.showText(*g, op.Args[0])
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// contextReader fails reads once ctx is done, which stops decompression of whatever is reading from it
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// contextReadSeeker is contextReader for pdfcpu, which reads the file object by object, seeking in between
+type contextReadSeeker struct {
+	ctx context.Context
+	io.ReadSeeker
+}
+
+func (crs *contextReadSeeker) Read(p []byte) (int, error) {
+	if err := crs.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return crs.ReadSeeker.Read(p)
+}
+
+// contextWriter fails writes once ctx is done
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (cw *contextWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(p)
+}
+
+// readContext is api.ReadContext which stops reading rs once ctx is done, returning ctx.Err() then
+func readContext(ctx context.Context, rs io.ReadSeeker, conf *pdfcpu.Configuration) (*pdfcpu.Context, error) {
+	pdfCtx, err := api.ReadContext(&contextReadSeeker{ctx, rs}, conf)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, newError(ErrNotIllustrator, err)
+	}
+	return pdfCtx, nil
+}
+
+// decodeStream is sd.Decode which feeds every filter through contextReader, so decoding stops once ctx is done
+func decodeStream(ctx context.Context, sd *pdfcpu.StreamDict) error {
+	if sd.Content != nil {
+		return nil
+	}
+	var r io.Reader = bytes.NewReader(sd.Raw)
+	for _, f := range sd.FilterPipeline {
+		fi, err := filter.NewFilter(f.Name, filterParms(sd, f))
+		if err != nil {
+			return err
+		}
+		if r, err = fi.Decode(&contextReader{ctx, r}); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return err
+		}
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	sd.Content = content
+	return nil
+}
+
+// filterParms returns integer and boolean DecodeParms of f same as pdfcpu does, CCITTFaxDecode gets Rows from Height
+func filterParms(sd *pdfcpu.StreamDict, f pdfcpu.PDFFilter) map[string]int {
+	parms := map[string]int{}
+	for key, val := range f.DecodeParms {
+		switch val := val.(type) {
+		case pdfcpu.Integer:
+			parms[key] = val.Value()
+		case pdfcpu.Boolean:
+			parms[key] = 0
+			if val.Value() {
+				parms[key] = 1
+			}
+		}
+	}
+	if _, ok := parms["Rows"]; f.Name == filter.CCITTFax && !ok {
+		if height := sd.IntEntry("Height"); height != nil {
+			parms["Rows"] = *height
+		}
+	}
+	return parms
+}
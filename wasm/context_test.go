@@ -0,0 +1,123 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// testContext is done once Err was called more than n times, so that cancellation lands in the middle of the work
+type testContext struct {
+	context.Context
+	n int
+
+	mu    sync.Mutex
+	calls int
+}
+
+func newTestContext(n int) *testContext {
+	return &testContext{Context: context.Background(), n: n}
+}
+
+func (c *testContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.calls > c.n {
+		return context.Canceled
+	}
+	return nil
+}
+
+// countingReadSeeker counts reads which got through to the file
+type countingReadSeeker struct {
+	io.ReadSeeker
+	reads int
+}
+
+func (crs *countingReadSeeker) Read(p []byte) (int, error) {
+	crs.reads++
+	return crs.ReadSeeker.Read(p)
+}
+
+func TestDecodeStreamContext(t *testing.T) {
+	// incompressible data, so that decoding takes many reads of raw stream
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	encoded := testEncodedStream(t, pdfcpu.NewDict(), data, filter.Flate)
+
+	sd := *encoded
+	ctx := newTestContext(1 << 30)
+	if err := decodeStream(ctx, &sd); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sd.Content, data) {
+		t.Fatal("decoded content differs")
+	}
+	if ctx.calls < 20 {
+		t.Errorf("ctx checked %d times, want once per read", ctx.calls)
+	}
+
+	sd = *encoded
+	ctx = newTestContext(3)
+	if err := decodeStream(ctx, &sd); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+	if sd.Content != nil {
+		t.Error("content set although decoding was cancelled")
+	}
+	// the failed check stops decoding, decodeStream only checks once more which error to return
+	if ctx.calls > 5 {
+		t.Errorf("ctx checked %d times after cancellation", ctx.calls-4)
+	}
+}
+
+func TestImageReaderContext(t *testing.T) {
+	dict := pdfcpu.NewDict()
+	dict.InsertName("Subtype", "Image")
+	dict.InsertInt("Width", 1024)
+	dict.InsertInt("Height", 1024)
+	dict.InsertInt("BitsPerComponent", 8)
+	dict.InsertName("ColorSpace", "DeviceGray")
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	sd := testEncodedStream(t, dict, data, filter.Flate)
+	ir := &imageReader{objNr: 1, sd: *sd}
+
+	for _, n := range []int{0, 3} {
+		ctx := newTestContext(n)
+		if _, err := ir.ReadContext(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled after %d checks: got %v, want %v", n, err, context.Canceled)
+		}
+		// decodeStream and dumpImage check once more which error to return
+		if ctx.calls > n+3 {
+			t.Errorf("cancelled after %d checks: ctx checked %d times after cancellation", n, ctx.calls-n-1)
+		}
+	}
+}
+
+func TestParseContextStopsReading(t *testing.T) {
+	data := testFullPDF()
+
+	full := &countingReadSeeker{ReadSeeker: bytes.NewReader(data)}
+	if _, err := ParseContext(context.Background(), full, testConfiguration()); err != nil {
+		t.Fatal(err)
+	}
+
+	rs := &countingReadSeeker{ReadSeeker: bytes.NewReader(data)}
+	ctx := newTestContext(2)
+	if _, err := ParseContext(ctx, rs, testConfiguration()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+	// the header is read once more on top of reads allowed by ctx, when looking for legacy files
+	if rs.reads > 3 || rs.reads >= full.reads {
+		t.Errorf("file read %d times once cancelled, %d times without cancellation", rs.reads, full.reads)
+	}
+}
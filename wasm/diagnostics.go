@@ -0,0 +1,218 @@
+package wasm
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// ValidationPolicy decides what happens when validation in the configured mode fails
+type ValidationPolicy int
+
+const (
+	// ValidationFail returns ValidationError right away
+	ValidationFail ValidationPolicy = iota
+	// ValidationRetryRelaxed records problems of strict validation in Diagnostics and validates again in
+	// pdfcpu.ValidationRelaxed mode, ValidationError is returned only if that fails as well
+	ValidationRetryRelaxed
+)
+
+// maxDiagnostics bounds validation problems collected for a single file, each of those takes validation passes
+const maxDiagnostics = 32
+
+// Diagnostic is a validation problem tolerated by ValidationRetryRelaxed, malformed XMP metadata or PieceInfo
+type Diagnostic struct {
+	ObjNr int
+	// Key is the offending entry of ObjNr, the one without which the problem goes away. It's empty if the problem is
+	// not down to a single entry.
+	Key     string
+	Message string
+}
+
+// validate runs api.ValidateContext according to conf.ValidationPolicy. pdfcpu stops at the first problem, so with
+// ValidationRetryRelaxed strict validation is repeated with each problem found set aside, see excusal, until it passes
+// or problems stop being separable. Every problem is recorded, then the file is restored and validated in relaxed mode.
+func validate(ctx context.Context, pdfCtx *pdfcpu.Context, conf *Configuration) ([]Diagnostic, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	err := api.ValidateContext(pdfCtx)
+	if err == nil {
+		return nil, nil
+	}
+	mode := pdfCtx.XRefTable.ValidationMode
+	if conf.ValidationPolicy != ValidationRetryRelaxed || mode == pdfcpu.ValidationRelaxed {
+		return nil, &ValidationError{ObjNr: pdfCtx.CurObj, Strict: mode == pdfcpu.ValidationStrict, Err: err}
+	}
+
+	ex := excusal{pdfCtx: pdfCtx, objects: map[int]pdfcpu.Object{}}
+	diagnostics, err := ex.collect(ctx, pdfCtx.CurObj, err)
+	ex.restore()
+	if err != nil {
+		return nil, err
+	}
+
+	// only the xref table is switched, conf stays as is for other files
+	pdfCtx.XRefTable.ValidationMode = pdfcpu.ValidationRelaxed
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := api.ValidateContext(pdfCtx); err != nil {
+		return diagnostics, &ValidationError{ObjNr: pdfCtx.CurObj, Err: err}
+	}
+	return diagnostics, nil
+}
+
+// excusal sets aside entries strict validation fails on, so that validating again finds the next problem
+type excusal struct {
+	pdfCtx *pdfcpu.Context
+	// objects holds original objects of entries changed
+	objects map[int]pdfcpu.Object
+}
+
+// collect records problem err at objNr and the ones found after setting it aside, it fails only once ctx is done.
+// A problem is set aside by dropping the offending entry of objNr, or if there is none (e.g. a required entry is
+// missing), entries of other objects referring to objNr.
+func (ex *excusal) collect(ctx context.Context, objNr int, err error) ([]Diagnostic, error) {
+	var diagnostics []Diagnostic
+	for err != nil && len(diagnostics) < maxDiagnostics {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		diagnostic := Diagnostic{ObjNr: objNr, Message: err.Error()}
+		next, key, ok := ex.dropEntry(objNr, nil, objNr)
+		if ok {
+			diagnostic.Key = key
+		} else {
+			next, ok = ex.dropReferences(objNr)
+		}
+		diagnostics = append(diagnostics, diagnostic)
+		if !ok {
+			break
+		}
+		err, objNr = next, ex.pdfCtx.CurObj
+	}
+	return diagnostics, nil
+}
+
+// dropReferences drops entries referring to objNr from other objects, in order of object numbers
+func (ex *excusal) dropReferences(objNr int) (error, bool) {
+	table := ex.pdfCtx.XRefTable.Table
+	referrers := make([]int, 0, len(table))
+	for refNr := range table {
+		referrers = append(referrers, refNr)
+	}
+	sort.Ints(referrers)
+	refersTo := func(val pdfcpu.Object) bool { return refersTo(val, objNr) }
+	for _, refNr := range referrers {
+		if refNr == objNr || !refersTo(table[refNr].Object) {
+			continue
+		}
+		if err, _, ok := ex.dropEntry(refNr, refersTo, objNr, refNr); ok {
+			return err, true
+		}
+	}
+	return nil, false
+}
+
+// dropEntry drops the first entry of objNr, among those accepted by filter (all if nil), after which validation no
+// longer fails at any of stuck objects. The entry stays dropped and the result of validation without it is returned.
+func (ex *excusal) dropEntry(objNr int, filter func(val pdfcpu.Object) bool, stuck ...int) (err error, key string, ok bool) {
+	entry, found := ex.pdfCtx.XRefTable.Table[objNr]
+	if !found || entry == nil || entry.Free {
+		return nil, "", false
+	}
+	original := entry.Object
+	dict := objectDict(original)
+	keys := make([]string, 0, len(dict))
+	for key, val := range dict {
+		if filter == nil || filter(val) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		ex.set(objNr, entry, withoutKey(original, key))
+		err := api.ValidateContext(ex.pdfCtx)
+		if err == nil || !containsInt(stuck, ex.pdfCtx.CurObj) {
+			return err, key, true
+		}
+	}
+	entry.Object = original
+	return nil, "", false
+}
+
+// set replaces object of entry, the original one is kept for restore
+func (ex *excusal) set(objNr int, entry *pdfcpu.XRefTableEntry, obj pdfcpu.Object) {
+	if _, ok := ex.objects[objNr]; !ok {
+		ex.objects[objNr] = entry.Object
+	}
+	entry.Object = obj
+}
+
+// restore puts back all objects changed
+func (ex *excusal) restore() {
+	for objNr, obj := range ex.objects {
+		ex.pdfCtx.XRefTable.Table[objNr].Object = obj
+	}
+}
+
+// refersTo tells whether obj refers to objNr, directly or by its direct dicts and arrays
+func refersTo(obj pdfcpu.Object, objNr int) bool {
+	switch obj := obj.(type) {
+	case pdfcpu.IndirectRef:
+		return obj.ObjectNumber.Value() == objNr
+	case pdfcpu.Dict:
+		for _, val := range obj {
+			if refersTo(val, objNr) {
+				return true
+			}
+		}
+	case pdfcpu.StreamDict:
+		return refersTo(obj.Dict, objNr)
+	case pdfcpu.Array:
+		for _, val := range obj {
+			if refersTo(val, objNr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// objectDict returns entries of dict and stream dict objects, nil for others
+func objectDict(obj pdfcpu.Object) pdfcpu.Dict {
+	switch obj := obj.(type) {
+	case pdfcpu.Dict:
+		return obj
+	case pdfcpu.StreamDict:
+		return obj.Dict
+	}
+	return nil
+}
+
+// withoutKey returns a copy of dict or stream dict obj without key, obj is left as is
+func withoutKey(obj pdfcpu.Object, key string) pdfcpu.Object {
+	dict := pdfcpu.Dict{}
+	for k, v := range objectDict(obj) {
+		if k != key {
+			dict[k] = v
+		}
+	}
+	if sd, ok := obj.(pdfcpu.StreamDict); ok {
+		sd.Dict = dict
+		return sd
+	}
+	return dict
+}
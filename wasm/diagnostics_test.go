@@ -0,0 +1,115 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// testStrictPDF fails strict validation at three places: ExtGState entries 9 CA and 10 BM need PDF 1.4, and
+// Illustrator dict 4 lacks required LastModified
+func testStrictPDF() []byte {
+	data := testIllustratorPDF(testIndexedLines, "0 0 100 100", "/ExtGState << /G1 9 0 R /G2 10 0 R >>", "/G1 gs /G2 gs",
+		"<< /Type /ExtGState /CA 0.5 /LW 2 >>",
+		"<< /Type /ExtGState /BM /Multiply >>",
+	)
+	return bytes.Replace(data, []byte("%PDF-1.5"), []byte("%PDF-1.3"), 1)
+}
+
+// testStrictContext reads testStrictPDF for strict validation
+func testStrictContext(t *testing.T, policy ValidationPolicy) (*pdfcpu.Context, *Configuration) {
+	t.Helper()
+	conf := testConfiguration()
+	conf.ValidationMode = pdfcpu.ValidationStrict
+	conf.ValidationPolicy = policy
+	pdfCtx, err := readContext(context.Background(), bytes.NewReader(testStrictPDF()), &conf.Configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pdfCtx, conf
+}
+
+func TestValidateFail(t *testing.T) {
+	pdfCtx, conf := testStrictContext(t, ValidationFail)
+	diagnostics, err := validate(context.Background(), pdfCtx, conf)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("got %v, want ValidationError", err)
+	}
+	// pdfcpu visits resources in map order
+	if validationErr.ObjNr != 9 && validationErr.ObjNr != 10 || !validationErr.Strict || diagnostics != nil {
+		t.Errorf("got %+v and diagnostics %+v", validationErr, diagnostics)
+	}
+}
+
+func TestValidateRetryRelaxed(t *testing.T) {
+	pdfCtx, conf := testStrictContext(t, ValidationRetryRelaxed)
+	diagnostics, err := validate(context.Background(), pdfCtx, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type problem struct {
+		objNr int
+		key   string
+	}
+	if len(diagnostics) != 3 {
+		t.Fatalf("got %+v, want 3 problems", diagnostics)
+	}
+	var got []problem
+	for _, d := range diagnostics {
+		got = append(got, problem{d.ObjNr, d.Key})
+		if d.Message == "" {
+			t.Errorf("obj %d: empty message", d.ObjNr)
+		}
+	}
+	// the Illustrator dict isn't down to a single entry, so it is set aside by dropping PieceInfo of the page. It comes
+	// last, the page is validated after its resources, which are visited in map order.
+	sort.Slice(got[:2], func(i, j int) bool { return got[i].objNr < got[j].objNr })
+	want := []problem{{9, "CA"}, {10, "BM"}, {4, ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if mode := pdfCtx.XRefTable.ValidationMode; mode != pdfcpu.ValidationRelaxed {
+		t.Errorf("validation mode %v, want relaxed", mode)
+	}
+	if conf.ValidationMode != pdfcpu.ValidationStrict {
+		t.Error("configuration switched to relaxed mode")
+	}
+	// entries dropped while collecting problems are back
+	for objNr, key := range map[int]string{9: "CA", 10: "BM", 3: "PieceInfo"} {
+		if _, ok := objectDict(pdfCtx.XRefTable.Table[objNr].Object)[key]; !ok {
+			t.Errorf("obj %d: %s not restored", objNr, key)
+		}
+	}
+}
+
+func TestValidateRetryRelaxedCancelled(t *testing.T) {
+	pdfCtx, conf := testStrictContext(t, ValidationRetryRelaxed)
+	ctx := newTestContext(2)
+	if _, err := validate(ctx, pdfCtx, conf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+	if _, ok := objectDict(pdfCtx.XRefTable.Table[9].Object)["CA"]; !ok {
+		t.Error("CA not restored once cancelled")
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	conf := testConfiguration()
+	conf.ValidationMode = pdfcpu.ValidationStrict
+	f, err := ParseContext(context.Background(), bytes.NewReader(testStrictPDF()), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.PrivateData.Close()
+	if len(f.Diagnostics) != 3 {
+		t.Errorf("got %+v, want every problem", f.Diagnostics)
+	}
+}
@@ -0,0 +1,158 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ErrorCode identifies class of failure, it's shared with JS as `code` of rejected promises
+type ErrorCode string
+
+const (
+	CodeNotIllustrator         ErrorCode = "NOT_ILLUSTRATOR"
+	CodeLegacyFormat           ErrorCode = "LEGACY_FORMAT"
+	CodeMissingPieceInfo       ErrorCode = "MISSING_PIECE_INFO"
+	CodeTruncatedPrivateData   ErrorCode = "TRUNCATED_PRIVATE_DATA"
+	CodeUnsupportedCompression ErrorCode = "UNSUPPORTED_COMPRESSION"
+	CodeValidation             ErrorCode = "VALIDATION"
+	CodeImageDecode            ErrorCode = "IMAGE_DECODE"
+	CodeLimitExceeded          ErrorCode = "LIMIT_EXCEEDED"
+	CodeCanceled               ErrorCode = "CANCELED"
+	CodeUnknown                ErrorCode = "UNKNOWN"
+)
+
+// Error is a failure identified only by its code, use errors.Is with sentinels below to check for it
+type Error struct {
+	Code    ErrorCode
+	Message string
+	// Err is the underlying cause, if any
+	Err error
+}
+
+var (
+	// ErrNotIllustrator - file cannot be read as PDF
+	ErrNotIllustrator = &Error{Code: CodeNotIllustrator, Message: "not an Illustrator file"}
+	// ErrLegacyFormat - pre-CS PostScript file, which is parsed only with Configuration.AllowLegacy
+	ErrLegacyFormat = &Error{Code: CodeLegacyFormat, Message: "legacy PostScript Illustrator file"}
+	// ErrMissingPieceInfo - PDF has no PieceInfo->Illustrator->Private, i.e. was saved without Illustrator editing capabilities
+	ErrMissingPieceInfo = &Error{Code: CodeMissingPieceInfo, Message: "missing PieceInfo->Illustrator->Private"}
+	// ErrTruncatedPrivateData - private data blocks are missing or end prematurely
+	ErrTruncatedPrivateData = &Error{Code: CodeTruncatedPrivateData, Message: "truncated private data"}
+	// ErrUnsupportedCompression - private data is compressed by an unknown method
+	ErrUnsupportedCompression = &Error{Code: CodeUnsupportedCompression, Message: "unsupported private data compression"}
+)
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors of the same code, so that errors.Is(err, ErrMissingPieceInfo) holds regardless of the cause
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func (e *Error) ErrorCode() ErrorCode {
+	return e.Code
+}
+
+// newError returns sentinel with a cause attached
+func newError(sentinel *Error, cause error) *Error {
+	return &Error{Code: sentinel.Code, Message: sentinel.Message, Err: cause}
+}
+
+// ValidationError is returned when pdfcpu validation fails - retrying with pdfcpu.ValidationRelaxed might help if Strict
+type ValidationError struct {
+	ObjNr  int
+	Strict bool
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	hint := ""
+	if e.Strict {
+		hint = " (try -mode=relaxed)"
+	}
+	return fmt.Sprintf("validation error (obj#:%d)%s: %s", e.ObjNr, hint, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ValidationError) ErrorCode() ErrorCode {
+	return CodeValidation
+}
+
+// ImageDecodeError is returned by ImageReader when image cannot be rendered
+type ImageDecodeError struct {
+	ObjNr int
+	Err   error
+}
+
+func (e *ImageDecodeError) Error() string {
+	return fmt.Sprintf("failed decoding image (obj#:%d): %s", e.ObjNr, e.Err)
+}
+
+func (e *ImageDecodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ImageDecodeError) ErrorCode() ErrorCode {
+	return CodeImageDecode
+}
+
+func (e *LimitError) ErrorCode() ErrorCode {
+	return CodeLimitExceeded
+}
+
+type codedError interface {
+	ErrorCode() ErrorCode
+}
+
+// CodeOf returns code of the first error in chain that has one, CodeUnknown otherwise
+func CodeOf(err error) ErrorCode {
+	var coded codedError
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return CodeCanceled
+	}
+	return CodeUnknown
+}
+
+// ObjNrOf returns object number carried by the first error in chain that has one, 0 otherwise
+func ObjNrOf(err error) int {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.ObjNr
+	}
+	var imageErr *ImageDecodeError
+	if errors.As(err, &imageErr) {
+		return imageErr.ObjNr
+	}
+	var limitErr *LimitError
+	if errors.As(err, &limitErr) {
+		return limitErr.ObjNr
+	}
+	return 0
+}
+
+// ErrorProperties returns properties describing err to JS callers - code and objNr when known
+func ErrorProperties(err error) map[string]interface{} {
+	props := map[string]interface{}{"code": string(CodeOf(err))}
+	if objNr := ObjNrOf(err); objNr != 0 {
+		props["objNr"] = objNr
+	}
+	return props
+}
@@ -0,0 +1,75 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestCodeOf(t *testing.T) {
+	cause := errors.New("cause")
+	tests := []struct {
+		name  string
+		err   error
+		code  ErrorCode
+		objNr int
+	}{
+		{"not illustrator", ErrNotIllustrator, CodeNotIllustrator, 0},
+		{"legacy", ErrLegacyFormat, CodeLegacyFormat, 0},
+		{"missing piece info", newError(ErrMissingPieceInfo, cause), CodeMissingPieceInfo, 0},
+		{"truncated", errors.WithMessage(newError(ErrTruncatedPrivateData, cause), "whilst reading AIPrivateData2"), CodeTruncatedPrivateData, 0},
+		{"compression", errors.Wrap(ErrUnsupportedCompression, "block 1"), CodeUnsupportedCompression, 0},
+		{"validation", errors.WithMessage(&ValidationError{ObjNr: 4, Strict: true, Err: cause}, "whilst validating"), CodeValidation, 4},
+		{"image", &ImageDecodeError{ObjNr: 12, Err: cause}, CodeImageDecode, 12},
+		{"limit", fmt.Errorf("page 1: %w", &LimitError{Limit: LimitStreamSize, Max: 1, Value: 2, ObjNr: 7}), CodeLimitExceeded, 7},
+		{"limit without object", &LimitError{Limit: LimitObjects, Max: 1, Value: 2}, CodeLimitExceeded, 0},
+		{"canceled", errors.WithMessage(context.Canceled, "whilst reading"), CodeCanceled, 0},
+		{"deadline", context.DeadlineExceeded, CodeCanceled, 0},
+		// the first coded error in chain wins
+		{"image of limit", &ImageDecodeError{ObjNr: 3, Err: &LimitError{Limit: LimitImagePixels, ObjNr: 3}}, CodeImageDecode, 3},
+		{"other", cause, CodeUnknown, 0},
+		{"nil", nil, CodeUnknown, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := CodeOf(tt.err); code != tt.code {
+				t.Errorf("code %s, want %s", code, tt.code)
+			}
+			if objNr := ObjNrOf(tt.err); objNr != tt.objNr {
+				t.Errorf("objNr %d, want %d", objNr, tt.objNr)
+			}
+			props := map[string]interface{}{"code": string(tt.code)}
+			if tt.objNr != 0 {
+				props["objNr"] = tt.objNr
+			}
+			if got := ErrorProperties(tt.err); !reflect.DeepEqual(got, props) {
+				t.Errorf("properties %v, want %v", got, props)
+			}
+		})
+	}
+}
+
+func TestSentinels(t *testing.T) {
+	sentinels := []*Error{ErrNotIllustrator, ErrLegacyFormat, ErrMissingPieceInfo, ErrTruncatedPrivateData, ErrUnsupportedCompression}
+	cause := errors.New("cause")
+	for _, sentinel := range sentinels {
+		err := errors.WithMessage(newError(sentinel, cause), "whilst parsing")
+		for _, other := range sentinels {
+			if got := errors.Is(err, other); got != (other == sentinel) {
+				t.Errorf("errors.Is(%s, %s) = %v", sentinel.Code, other.Code, got)
+			}
+		}
+		if !errors.Is(err, cause) {
+			t.Errorf("%s: cause lost", sentinel.Code)
+		}
+		if want := "whilst parsing: " + sentinel.Message + ": cause"; err.Error() != want {
+			t.Errorf("got %q, want %q", err.Error(), want)
+		}
+		if sentinel.Err != nil {
+			t.Errorf("%s: sentinel changed by newError", sentinel.Code)
+		}
+	}
+}
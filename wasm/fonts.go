@@ -1,15 +1,20 @@
 package wasm
 
 import (
+	"context"
+
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
 )
 
 type Fonts map[int]*pdfcpu.Font
 
-func extractFonts(ctx *pdfcpu.Context) (fs Fonts, err error) {
+func extractFonts(ctx context.Context, pdfCtx *pdfcpu.Context) (fs Fonts, err error) {
 	fs = make(Fonts)
-	for objNr := range ctx.Optimize.FontObjects {
-		font, err := ctx.ExtractFont(objNr)
+	for objNr := range pdfCtx.Optimize.FontObjects {
+		if err := ctx.Err(); err != nil {
+			return fs, err
+		}
+		font, err := pdfCtx.ExtractFont(objNr)
 		if err != nil {
 			return fs, err
 		}
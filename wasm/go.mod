@@ -5,9 +5,9 @@ go 1.16
 replace github.com/pdfcpu/pdfcpu => ../vendor/pdfcpu.git
 
 require (
-	github.com/hhrutter/tiff v0.0.0-20190829141212-736cae8d0bc7 // indirect
-	github.com/klauspost/compress v1.15.1 // indirect
-	github.com/pdfcpu/pdfcpu v0.3.13 // indirect
-	github.com/pkg/errors v0.9.1 // indirect
-	github.com/pkg/profile v1.6.0 // indirect
+	github.com/hhrutter/tiff v0.0.0-20190829141212-736cae8d0bc7
+	github.com/klauspost/compress v1.15.1
+	github.com/pdfcpu/pdfcpu v0.3.13
+	github.com/pkg/errors v0.9.1
+	github.com/pkg/profile v1.6.0
 )
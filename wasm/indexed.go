@@ -0,0 +1,181 @@
+package wasm
+
+import (
+	"bufio"
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Spool keeps decompressed private data for IndexedPrivateData, e.g. *os.File created by os.CreateTemp
+type Spool interface {
+	io.Writer
+	io.ReaderAt
+}
+
+// IndexedPrivateData is private data decompressed once into Spool, together with offsets of its sections.
+// Unlike PrivateData it can be read repeatedly, from any offset or just a single section.
+type IndexedPrivateData struct {
+	spool    Spool
+	size     int64
+	sections []*Section
+}
+
+// IndexPrivateData reads pd to the end, writing lines to spool (in memory if nil) and recording sections on the way.
+// pd is closed once read.
+func IndexPrivateData(ctx context.Context, pd PrivateData, spool Spool) (*IndexedPrivateData, error) {
+	defer pd.Close()
+	if spool == nil {
+		spool = &memorySpool{}
+	}
+	tee := teePrivateData{PrivateData: pd, w: bufio.NewWriter(spool)}
+	ipd := IndexedPrivateData{spool: spool}
+	ss := NewSectionSplitter(&tee)
+	for ss.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ipd.sections = append(ipd.sections, ss.Section())
+	}
+	if err := ss.Err(); err != nil {
+		return nil, errors.WithMessage(err, "while indexing private data")
+	}
+	if err := tee.w.Flush(); err != nil {
+		return nil, errors.Wrap(err, "while spooling private data")
+	}
+	ipd.size = tee.written
+	return &ipd, nil
+}
+
+// Size returns length of decompressed private data, section offsets are within it
+func (ipd *IndexedPrivateData) Size() int64 {
+	return ipd.size
+}
+
+// Sections returns all sections in order they were finished, i.e. nested sections before the enclosing one
+func (ipd *IndexedPrivateData) Sections() []*Section {
+	return ipd.sections
+}
+
+// Section returns the first finished section of given name - e.g. TextDocument or an artboard name - nil if there is none
+func (ipd *IndexedPrivateData) Section(name string) *Section {
+	for _, section := range ipd.sections {
+		if section.Name == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// Reader returns PrivateData from the beginning, same as the one indexed
+func (ipd *IndexedPrivateData) Reader(ctx context.Context) PrivateData {
+	return ipd.Seek(ctx, 0)
+}
+
+// Seek returns PrivateData starting at offset, which should be the start of a line - e.g. Section.Start
+func (ipd *IndexedPrivateData) Seek(ctx context.Context, offset int64) PrivateData {
+	return ipd.reader(ctx, offset, ipd.size)
+}
+
+// SectionReader returns PrivateData of the section only, markers included
+func (ipd *IndexedPrivateData) SectionReader(ctx context.Context, section *Section) PrivateData {
+	return ipd.reader(ctx, section.Start, section.End)
+}
+
+func (ipd *IndexedPrivateData) reader(ctx context.Context, start, end int64) PrivateData {
+	return &closer{lineReader: newLineReader(&contextReader{ctx, io.NewSectionReader(ipd.spool, start, end-start)}, false)}
+}
+
+// teePrivateData writes lines to w as they are scanned, terminated by \r same as in private data - parts of long lines are not
+type teePrivateData struct {
+	PrivateData
+	w       *bufio.Writer
+	written int64
+	err     error
+}
+
+func (tee *teePrivateData) Scan() bool {
+	if tee.err != nil || !tee.PrivateData.Scan() {
+		return false
+	}
+	line := tee.PrivateData.Bytes()
+	_, tee.err = tee.w.Write(line)
+	tee.written += int64(len(line))
+	if tee.err == nil && !tee.Continued() {
+		tee.err = tee.w.WriteByte('\r')
+		tee.written++
+	}
+	return tee.err == nil
+}
+
+func (tee *teePrivateData) Err() error {
+	if tee.err != nil {
+		return errors.Wrap(tee.err, "while spooling private data")
+	}
+	return tee.PrivateData.Err()
+}
+
+// memorySpool is the default Spool
+type memorySpool struct {
+	data []byte
+}
+
+func (ms *memorySpool) Write(p []byte) (int, error) {
+	ms.data = append(ms.data, p...)
+	return len(p), nil
+}
+
+func (ms *memorySpool) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(ms.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, ms.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// IndexedPrivateData returns private data of f indexed on the first call, it is read by a scanner of its own, so
+// PrivateData is left untouched. Artboards, Layers, Swatches, Symbols, PlacedItems and TextLayers read from it,
+// each by a new reader. It's safe for concurrent use, private data is indexed only once then.
+func (f *IllustratorFile) IndexedPrivateData(ctx context.Context) (*IndexedPrivateData, error) {
+	f.indexedMu.Lock()
+	defer f.indexedMu.Unlock()
+	if f.indexed != nil {
+		return f.indexed, nil
+	}
+	var pd PrivateData
+	switch {
+	case f.Legacy != nil:
+		pd = f.Legacy.privateData(ctx)
+	case len(f.PrivateStreams) > 0:
+		var err error
+		if pd, err = f.PrivateStreams[0].Open(ctx); err != nil {
+			return nil, errors.WithMessage(err, "while opening private data")
+		}
+	default:
+		return nil, errors.New("private data was not requested, see Configuration.WithPrivateData")
+	}
+	indexed, err := IndexPrivateData(ctx, pd, nil)
+	if err != nil {
+		return nil, err
+	}
+	f.indexed = indexed
+	return indexed, nil
+}
+
+// privateData returns a new reader from the beginning of indexed private data
+func (f *IllustratorFile) privateData(ctx context.Context) (PrivateData, error) {
+	indexed, err := f.IndexedPrivateData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return indexed.Reader(ctx), nil
+}
+
+// hasPrivateData tells whether private data was requested and found
+func (f *IllustratorFile) hasPrivateData() bool {
+	return f.Legacy != nil || len(f.PrivateStreams) > 0
+}
@@ -0,0 +1,132 @@
+package wasm
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+var testIndexedLines = []string{
+	"%%BeginSetup",
+	"%AI5_BeginLayer",
+	"Layer one",
+	"%AI5_EndLayer--",
+	"%AI11_BeginTextDocument",
+	"/AI11TextDocument : /ASCII85Decode ,",
+	"%AI11_EndTextDocument",
+	"%%EndSetup",
+}
+
+// testReadLines reads pd to the end
+func testReadLines(t *testing.T, pd PrivateData) []string {
+	t.Helper()
+	defer pd.Close()
+	var lines []string
+	for pd.Scan() {
+		lines = append(lines, string(pd.Bytes()))
+	}
+	if err := pd.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+func TestIndexPrivateData(t *testing.T) {
+	ctx := context.Background()
+	ipd, err := IndexPrivateData(ctx, testPrivateData(testIndexedLines...), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, section := range ipd.Sections() {
+		names = append(names, section.Name)
+	}
+	if want := []string{"Layer", "TextDocument", "Setup"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("sections %q, want %q", names, want)
+	}
+	size := int64(0)
+	for _, line := range testIndexedLines {
+		size += int64(len(line)) + 1
+	}
+	if ipd.Size() != size {
+		t.Errorf("size %d, want %d", ipd.Size(), size)
+	}
+
+	for idx := 0; idx < 2; idx++ {
+		if lines := testReadLines(t, ipd.Reader(ctx)); !reflect.DeepEqual(lines, testIndexedLines) {
+			t.Errorf("read #%d: got %q, want %q", idx+1, lines, testIndexedLines)
+		}
+	}
+
+	section := ipd.Section("TextDocument")
+	if section == nil {
+		t.Fatal("TextDocument not found")
+	}
+	if lines := testReadLines(t, ipd.SectionReader(ctx, section)); !reflect.DeepEqual(lines, testIndexedLines[4:7]) {
+		t.Errorf("section: got %q, want %q", lines, testIndexedLines[4:7])
+	}
+	if lines := testReadLines(t, ipd.Seek(ctx, section.Start)); !reflect.DeepEqual(lines, testIndexedLines[4:]) {
+		t.Errorf("seek: got %q, want %q", lines, testIndexedLines[4:])
+	}
+	if ipd.Section("Missing") != nil {
+		t.Error("found section which is not there")
+	}
+}
+
+// testFileLines are lines of testIllustratorFile with testIndexedLines, AIMetaData comes first
+var testFileLines = append([]string{"%!PS-Adobe-3.0"}, testIndexedLines...)
+
+func TestIllustratorFileIndexedPrivateData(t *testing.T) {
+	ctx := context.Background()
+	f := testIllustratorFile(t, testIndexedLines, "0 0 100 100", "", "")
+	first, err := f.IndexedPrivateData(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := f.IndexedPrivateData(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("private data indexed twice")
+	}
+	for idx := 0; idx < 2; idx++ {
+		if lines := testReadLines(t, second.Reader(ctx)); !reflect.DeepEqual(lines, testFileLines) {
+			t.Errorf("read #%d: got %q, want %q", idx+1, lines, testFileLines)
+		}
+	}
+	// PrivateData is left for the caller
+	if lines := testReadLines(t, f.PrivateData); !reflect.DeepEqual(lines, testFileLines) {
+		t.Errorf("PrivateData: got %q, want %q", lines, testFileLines)
+	}
+}
+
+func TestIllustratorFileIndexedPrivateDataConcurrent(t *testing.T) {
+	ctx := context.Background()
+	f := testIllustratorFile(t, testIndexedLines, "0 0 100 100", "", "")
+	const readers = 8
+	indexed := make([]*IndexedPrivateData, readers)
+	errs := make([]error, readers)
+	var wg sync.WaitGroup
+	for idx := 0; idx < readers; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			indexed[idx], errs[idx] = f.IndexedPrivateData(ctx)
+		}(idx)
+	}
+	wg.Wait()
+	for idx := range indexed {
+		if errs[idx] != nil {
+			t.Fatal(errs[idx])
+		}
+		if indexed[idx] != indexed[0] {
+			t.Errorf("reader #%d got private data indexed again", idx)
+		}
+	}
+	if lines := testReadLines(t, indexed[0].Reader(ctx)); !reflect.DeepEqual(lines, testFileLines) {
+		t.Errorf("got %q, want %q", lines, testFileLines)
+	}
+}
@@ -0,0 +1,213 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// LayerColor is the highlight colour of a layer, shown in the layers panel and on selection
+type LayerColor struct {
+	R uint8 `json:"r"`
+	G uint8 `json:"g"`
+	B uint8 `json:"b"`
+}
+
+// Layer is a %AI5_BeginLayer ... %AI5_EndLayer record, sublayers are nested records
+type Layer struct {
+	Name    string `json:"name"`
+	Visible bool   `json:"visible"`
+	Locked  bool   `json:"locked"`
+	// Printable is false for non-printing layers, e.g. templates
+	Printable bool `json:"printable"`
+	// Preview is false for layers in outline mode
+	Preview bool `json:"preview"`
+	// Dimmed tells whether images of the layer are dimmed
+	Dimmed bool `json:"dimmed"`
+	// ColorIndex points to the list of predefined highlight colours, Color holds the actual value
+	ColorIndex int        `json:"colorIndex"`
+	Color      LayerColor `json:"color"`
+	// ZOrder is position among siblings, 0 being the bottom-most - layers are written bottom-up
+	ZOrder int `json:"zOrder"`
+	// OCG is object number of the optional content group created for top-level layer, 0 if there is none
+	OCG       int      `json:"ocg,omitempty"`
+	Sublayers []*Layer `json:"sublayers,omitempty"`
+
+	hasAttributes bool
+}
+
+var (
+	beginLayerRe = regexp.MustCompile(`^%AI\d+_BeginLayer`)
+	endLayerRe   = regexp.MustCompile(`^%AI\d+_EndLayer`)
+)
+
+// ReadLayers returns top-level layers of private data, bottom-most first. It consumes pd.
+func ReadLayers(ctx context.Context, pd PrivateData) ([]*Layer, error) {
+	var roots []*Layer
+	var stack []*Layer
+	continuation := false
+	for pd.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		line := pd.Bytes()
+		lineStart := !continuation
+		continuation = pd.Continued()
+		if !lineStart || len(line) < 2 {
+			continue
+		}
+		switch {
+		case beginLayerRe.Match(line):
+			stack = append(stack, &Layer{})
+		case endLayerRe.Match(line):
+			if len(stack) == 0 {
+				continue
+			}
+			layer := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				layer.ZOrder = len(roots)
+				roots = append(roots, layer)
+			} else {
+				parent := stack[len(stack)-1]
+				layer.ZOrder = len(parent.Sublayers)
+				parent.Sublayers = append(parent.Sublayers, layer)
+			}
+		case len(stack) > 0 && (bytes.HasSuffix(line, []byte(" Lb")) || bytes.HasSuffix(line, []byte(" Ln"))):
+			// only the first record of each kind belongs to the layer, nested layers have their own
+			if err := stack[len(stack)-1].parseRecord(line); err != nil {
+				return nil, errors.WithMessagef(err, "layer %q", line)
+			}
+		}
+	}
+	if err := pd.Err(); err != nil {
+		return nil, errors.WithMessage(err, "while reading layers")
+	}
+	return roots, nil
+}
+
+// parseRecord handles layer attributes (Lb) and name (Ln)
+func (layer *Layer) parseRecord(line []byte) error {
+	parser := contents.NewParser(line)
+	if !parser.Scan() {
+		return parser.Err()
+	}
+	op := parser.Operator()
+	switch op.Name {
+	case "Lb":
+		if !layer.hasAttributes {
+			layer.hasAttributes = true
+			return layer.parseAttributes(op.Args)
+		}
+	case "Ln":
+		if len(op.Args) == 1 && layer.Name == "" {
+			if s, ok := op.Args[0].(contents.LiteralString); ok {
+				layer.Name = layerName(s.Unescape())
+			}
+		}
+	}
+	return nil
+}
+
+// parseAttributes decodes `visible preview enabled printing dimmed hasMultiLayerMasks colorIndex red green blue Lb`.
+// Newer files write further operands after the colour, e.g. `1 1 1 1 0 0 0 79 128 255 0 50 Lb`.
+func (layer *Layer) parseAttributes(args []contents.Operand) error {
+	values, err := numbers(args)
+	if err != nil {
+		return errors.WithMessage(err, "Lb")
+	}
+	ops := make([]int, len(values))
+	for idx, value := range values {
+		ops[idx] = int(value)
+	}
+	if len(ops) < 10 {
+		return errors.Errorf("Lb has %d operands, at least 10 expected", len(ops))
+	}
+	layer.Visible = ops[0] != 0
+	layer.Preview = ops[1] != 0
+	layer.Locked = ops[2] == 0
+	layer.Printable = ops[3] != 0
+	layer.Dimmed = ops[4] != 0
+	layer.ColorIndex = ops[6]
+	layer.Color = LayerColor{uint8(ops[7]), uint8(ops[8]), uint8(ops[9])}
+	return nil
+}
+
+// layerName decodes UTF-16BE names with BOM, others are taken as UTF-8 falling back to Latin-1
+func layerName(raw []byte) string {
+	if name, err := decodeUTF16BE(raw); err == nil {
+		return name
+	}
+	if utf8.Valid(raw) {
+		return string(raw)
+	}
+	runes := make([]rune, len(raw))
+	for idx, b := range raw {
+		runes[idx] = rune(b)
+	}
+	return string(runes)
+}
+
+// Layers reads layers from private data and maps top-level ones to optional content groups by name, in order of
+// OCProperties->OCGs. Private data is read from IllustratorFile.IndexedPrivateData.
+func (f *IllustratorFile) Layers(ctx context.Context) ([]*Layer, error) {
+	pd, err := f.privateData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer pd.Close()
+	layers, err := ReadLayers(ctx, pd)
+	if err != nil {
+		return nil, err
+	}
+	if f.SerializedFile != nil {
+		if err := mapOCGs(&f.SerializedFile.XRefTable, layers); err != nil {
+			return nil, errors.WithMessage(err, "while mapping layers to OCGs")
+		}
+	}
+	return layers, nil
+}
+
+// mapOCGs sets Layer.OCG of top-level layers, the n-th layer of a name gets the n-th OCG of the same name
+func mapOCGs(xRefTable *pdfcpu.XRefTable, layers []*Layer) error {
+	root, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+	ocProperties, err := xRefTable.DereferenceDict(root["OCProperties"])
+	if err != nil || ocProperties == nil {
+		return err
+	}
+	ocgs, err := xRefTable.DereferenceArray(ocProperties["OCGs"])
+	if err != nil {
+		return err
+	}
+	byName := map[string][]int{}
+	for _, obj := range ocgs {
+		ref, ok := obj.(pdfcpu.IndirectRef)
+		if !ok {
+			continue
+		}
+		ocg, err := xRefTable.DereferenceDict(ref)
+		if err != nil {
+			return errors.WithMessagef(err, "OCG %s", ref)
+		}
+		name, err := xRefTable.DereferenceStringOrHexLiteral(ocg["Name"], pdfcpu.V10, nil)
+		if err != nil {
+			continue
+		}
+		byName[name] = append(byName[name], ref.ObjectNumber.Value())
+	}
+	for _, layer := range layers {
+		if refs := byName[layer.Name]; len(refs) > 0 {
+			layer.OCG = refs[0]
+			byName[layer.Name] = refs[1:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package wasm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
+)
+
+func TestLayerAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected Layer
+		fails    bool
+	}{
+		{
+			name: "current",
+			line: "1 1 1 1 0 0 0 79 128 255 0 50 Lb",
+			expected: Layer{
+				Visible: true, Preview: true, Printable: true, Color: LayerColor{79, 128, 255},
+			},
+		},
+		{
+			name: "hidden locked template",
+			line: "0 0 0 0 1 0 3 255 0 0 Lb",
+			expected: Layer{
+				Locked: true, Dimmed: true, ColorIndex: 3, Color: LayerColor{255, 0, 0},
+			},
+		},
+		{
+			name:  "too few operands",
+			line:  "1 1 1 1 0 0 0 79 128 Lb",
+			fails: true,
+		},
+		{
+			name:  "not a number",
+			line:  "1 1 1 1 0 0 0 79 128 /Blue Lb",
+			fails: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := contents.NewParser([]byte(tt.line))
+			if !parser.Scan() {
+				t.Fatal(parser.Err())
+			}
+			var layer Layer
+			err := layer.parseAttributes(parser.Operator().Args)
+			if tt.fails {
+				if err == nil {
+					t.Errorf("expected error, got %+v", layer)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(layer, tt.expected) {
+				t.Errorf("got %+v, expected %+v", layer, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadLayers(t *testing.T) {
+	pd := testPrivateData(
+		"%AI5_BeginLayer",
+		"1 1 1 1 0 0 0 79 128 255 0 50 Lb",
+		"(Layer 1) Ln",
+		"%AI5_BeginLayer",
+		"0 1 1 1 0 0 3 255 0 0 0 50 Lb",
+		`(Sub \(a\) \351) Ln`,
+		"%AI5_EndLayer--",
+		"%AI5_BeginLayer",
+		"1 1 1 1 0 0 2 255 79 79 0 50 Lb",
+		"(\xfe\xff\x00S\x00u\x00b\x00 \x002) Ln",
+		"%AI5_EndLayer--",
+		"%AI5_EndLayer--",
+		"%AI5_BeginLayer",
+		"1 1 0 1 0 0 1 79 255 79 0 50 Lb",
+		"(Top) Ln",
+		"%AI5_EndLayer--",
+	)
+	layers, err := ReadLayers(context.Background(), pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := func(layer Layer) *Layer {
+		layer.Preview, layer.Printable, layer.hasAttributes = true, true, true
+		return &layer
+	}
+	expected := []*Layer{
+		attrs(Layer{
+			Name: "Layer 1", Visible: true, Color: LayerColor{79, 128, 255},
+			Sublayers: []*Layer{
+				attrs(Layer{Name: "Sub (a) é", ColorIndex: 3, Color: LayerColor{255, 0, 0}}),
+				attrs(Layer{Name: "Sub 2", Visible: true, ColorIndex: 2, Color: LayerColor{255, 79, 79}, ZOrder: 1}),
+			},
+		}),
+		attrs(Layer{Name: "Top", Visible: true, Locked: true, ColorIndex: 1, Color: LayerColor{79, 255, 79}, ZOrder: 1}),
+	}
+	if !reflect.DeepEqual(layers, expected) {
+		t.Errorf("got %+v, expected %+v", layers, expected)
+	}
+}
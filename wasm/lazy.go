@@ -0,0 +1,247 @@
+package wasm
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// Document is a handle to a file read by ParseLazy - stream dicts, bitmaps and fonts are resolved per page on demand.
+// NOTE: pdfcpu still reads the whole xref table, laziness covers everything Parse builds on top of it.
+// Document is safe for concurrent use, pages are resolved one at a time though.
+type Document struct {
+	// PrivateData is the first of PrivateStreams, opened
+	PrivateData PrivateData
+	// PrivateStreams lists every distinct private dict together with pages referring to it
+	PrivateStreams []PrivateStream
+	// PrivateDataAbsent is the reason why PrivateData is nil despite being requested, see Configuration.AllowMissingPrivateData
+	PrivateDataAbsent error
+	// Diagnostics hold every strict validation problem tolerated thanks to Configuration.ValidationPolicy, up to 32 of
+	// them, malformed XMP metadata and malformed PieceInfo of pages when private data is found elsewhere
+	Diagnostics []Diagnostic
+	// Legacy is set for pre-CS PostScript files, which have no pages, see Configuration.AllowLegacy
+	Legacy *LegacyInfo
+	// XMP is parsed from the catalog Metadata stream, nil if there is none or it is malformed - see Diagnostics
+	XMP *XMP
+
+	// mu guards ctx, which is updated by page count and font extraction
+	mu      sync.Mutex
+	ctx     *pdfcpu.Context
+	limiter *limiter
+}
+
+// Page holds objects reachable from a single page, keyed by object number same as in IllustratorFile
+type Page struct {
+	Number      int
+	Ref         pdfcpu.IndirectRef
+	StreamDicts StreamDicts
+	Bitmaps     Bitmaps
+	Fonts       Fonts
+}
+
+// ParseLazy reads and validates the file, extracting private data if requested - other Configuration options apply to Page.
+func ParseLazy(rs io.ReadSeeker, conf *Configuration) (*Document, error) {
+	return ParseLazyContext(context.Background(), rs, conf)
+}
+
+// ParseLazyContext is ParseLazy which stops once ctx is done, see ParseContext.
+func ParseLazyContext(ctx context.Context, rs io.ReadSeeker, conf *Configuration) (*Document, error) {
+	var doc Document
+	var s Stats
+	s.Observe("start")
+	defer s.Report()
+
+	doc.limiter = newLimiter(conf.Limits)
+	legacy, err := readLegacy(ctx, rs, conf, doc.limiter)
+	if err != nil {
+		return nil, err
+	}
+	if legacy != nil {
+		doc.Legacy = legacy
+		if conf.WithPrivateData || conf.PrivateDataOnly {
+			doc.PrivateData = legacy.privateData(ctx)
+		}
+		return &doc, nil
+	}
+
+	pdfCtx, err := readContext(ctx, rs, &conf.Configuration)
+	if err != nil {
+		return nil, errors.WithMessage(err, "while opening read context")
+	}
+	s.Observe("read")
+
+	if err := doc.limiter.checkObjects(pdfCtx.XRefTable); err != nil {
+		return nil, err
+	}
+
+	if !conf.PrivateDataOnly {
+		if doc.Diagnostics, err = validate(ctx, pdfCtx, conf); err != nil {
+			return nil, errors.WithMessage(err, "whilst validating")
+		}
+		s.Observe("validate")
+
+		doc.XMP = extractXMP(pdfCtx.XRefTable, &doc.Diagnostics)
+		s.Observe("xmp")
+	}
+
+	if conf.WithPrivateData || conf.PrivateDataOnly {
+		doc.PrivateData, doc.PrivateStreams, err = extractPrivateData(ctx, pdfCtx, doc.limiter, &doc.Diagnostics)
+		s.Observe("private data")
+		if !conf.PrivateDataOnly && conf.AllowMissingPrivateData && errors.Is(err, ErrMissingPieceInfo) {
+			doc.PrivateDataAbsent, err = err, nil
+		}
+		if err != nil {
+			return nil, errors.WithMessage(err, "whilst extracting private data")
+		}
+	}
+
+	// fonts are registered by Page, as found
+	pdfCtx.Optimize = &pdfcpu.OptimizationContext{FontObjects: map[int]*pdfcpu.FontObject{}}
+	doc.ctx = pdfCtx
+	return &doc, nil
+}
+
+// PageCount returns number of pages (artboards) in the document, 0 for legacy files.
+func (d *Document) PageCount() (int, error) {
+	if d.Legacy != nil {
+		return 0, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.ctx.EnsurePageCount(); err != nil {
+		return 0, err
+	}
+	return d.ctx.PageCount, nil
+}
+
+// Page resolves objects reachable from page pageNr (starting at 1), inherited resources included.
+// Neither Parent links nor references to other pages are followed, so other pages are never visited.
+func (d *Document) Page(ctx context.Context, pageNr int, conf *Configuration) (*Page, error) {
+	if d.Legacy != nil {
+		return nil, errors.Errorf("page %d not found, legacy file has no pages", pageNr)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	xRefTable := d.ctx.XRefTable
+	pageDict, ref, _, err := xRefTable.PageDict(pageNr, false)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "while looking for page %d", pageNr)
+	}
+	if pageDict == nil || ref == nil {
+		return nil, errors.Errorf("page %d not found", pageNr)
+	}
+	page := Page{
+		Number:      pageNr,
+		Ref:         *ref,
+		StreamDicts: make(StreamDicts),
+		Bitmaps:     make(Bitmaps),
+		Fonts:       make(Fonts),
+	}
+
+	visited := map[int]bool{ref.ObjectNumber.Value(): true}
+	var walk func(obj pdfcpu.Object) error
+	walk = func(obj pdfcpu.Object) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		switch obj := obj.(type) {
+		case pdfcpu.IndirectRef:
+			objNr := obj.ObjectNumber.Value()
+			if visited[objNr] {
+				return nil
+			}
+			visited[objNr] = true
+			val, err := xRefTable.Dereference(obj)
+			if err != nil {
+				return errors.WithMessagef(err, "object %d", objNr)
+			}
+			if dict, ok := val.(pdfcpu.Dict); ok {
+				// e.g. link destinations point to other pages
+				if typ := dict.Type(); typ != nil && (*typ == "Page" || *typ == "Pages") {
+					return nil
+				}
+			}
+			if err := d.register(&page, objNr, val, conf); err != nil {
+				return errors.WithMessagef(err, "object %d", objNr)
+			}
+			return walk(val)
+		case pdfcpu.Dict:
+			for key, val := range obj {
+				if key != "Parent" {
+					if err := walk(val); err != nil {
+						return err
+					}
+				}
+			}
+		case pdfcpu.StreamDict:
+			return walk(obj.Dict)
+		case pdfcpu.Array:
+			for _, val := range obj {
+				if err := walk(val); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(pageDict); err != nil {
+		return nil, errors.WithMessagef(err, "page %d", pageNr)
+	}
+	// Resources may be inherited from the Pages tree
+	if _, ok := pageDict.Find("Resources"); !ok {
+		for parent := pageDict.IndirectRefEntry("Parent"); parent != nil; {
+			node, err := xRefTable.DereferenceDict(*parent)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "page %d parent", pageNr)
+			}
+			if resources, ok := node.Find("Resources"); ok {
+				if err := walk(resources); err != nil {
+					return nil, errors.WithMessagef(err, "page %d", pageNr)
+				}
+				break
+			}
+			parent = node.IndirectRefEntry("Parent")
+		}
+	}
+	return &page, nil
+}
+
+// register adds obj to respective collection of page, same split as in extractStreamDicts and extractFonts
+func (d *Document) register(page *Page, objNr int, obj pdfcpu.Object, conf *Configuration) error {
+	switch obj := obj.(type) {
+	case pdfcpu.StreamDict:
+		if subtype := obj.Dict.NameEntry("Subtype"); subtype != nil && *subtype == "Image" {
+			if !conf.SkipBitmaps {
+				page.Bitmaps[objNr] = &imageReader{d.ctx.XRefTable, objNr, obj, d.limiter}
+			}
+			return nil
+		}
+		if err := d.limiter.checkStream(objNr, &obj); err != nil {
+			return err
+		}
+		page.StreamDicts[objNr] = &obj
+	case pdfcpu.Dict:
+		if typ := obj.Type(); conf.SkipFonts || typ == nil || *typ != "Font" {
+			return nil
+		}
+		fontObject, ok := d.ctx.Optimize.FontObjects[objNr]
+		if !ok {
+			fontObject = &pdfcpu.FontObject{FontDict: obj}
+			if name := obj.NameEntry("BaseFont"); name != nil {
+				fontObject.FontName = *name
+			}
+			d.ctx.Optimize.FontObjects[objNr] = fontObject
+		}
+		font, err := d.ctx.ExtractFont(objNr)
+		if err != nil {
+			return err
+		}
+		if font != nil {
+			page.Fonts[objNr] = font
+		}
+	}
+	return nil
+}
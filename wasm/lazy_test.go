@@ -0,0 +1,45 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestDocumentConcurrentPages(t *testing.T) {
+	data := testIllustratorPDF(nil, "0 0 100 100", "/Font << /F1 9 0 R >> /XObject << /X0 10 0 R >>",
+		"BT /F1 12 Tf (Hello) Tj ET /X0 Do",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		testStream("/Type /XObject /Subtype /Form /BBox [0 0 10 10]", "0 0 m 10 10 l S"))
+	conf := testConfiguration()
+	conf.Limits = Limits{MaxStreamSize: 1 << 10, MaxAllocation: 1 << 20}
+	doc, err := ParseLazy(bytes.NewReader(data), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.PrivateData.Close()
+
+	var wg sync.WaitGroup
+	pages := make([]*Page, 8)
+	errs := make([]error, len(pages))
+	for idx := range pages {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			pages[idx], errs[idx] = doc.Page(context.Background(), 1, conf)
+		}(idx)
+	}
+	wg.Wait()
+	for idx, page := range pages {
+		if errs[idx] != nil {
+			t.Fatal(errs[idx])
+		}
+		if page.StreamDicts[10] == nil {
+			t.Errorf("call %d got stream dicts %v", idx, page.StreamDicts)
+		}
+	}
+	if doc.ctx.Optimize.FontObjects[9] == nil {
+		t.Errorf("font was not registered")
+	}
+}
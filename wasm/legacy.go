@@ -0,0 +1,176 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// LegacyInfo describes pre-CS (AI 8 to 10) file, which is PostScript with %AI comments instead of PDF with private data.
+// Whole PostScript is what PrivateData is for newer files, so it's exposed by the same scanner.
+type LegacyInfo struct {
+	// Creator is the %%Creator comment, e.g. "Adobe Illustrator(R) 8.0"
+	Creator string
+	// BoundingBox and HiResBoundingBox are bounds of the artwork, nil if missing or deferred to trailer by (atend)
+	BoundingBox      *pdfcpu.Rectangle
+	HiResBoundingBox *pdfcpu.Rectangle
+	// ArtboardBox is built from %AI3_TemplateBox (centre of the artboard) and %AI5_ArtSize, nil if either is missing
+	ArtboardBox *pdfcpu.Rectangle
+
+	data []byte
+}
+
+var (
+	postScriptMagic = []byte("%!PS-Adobe")
+	// DOS EPS binary header is followed by PostScript offset and length, both uint32 LE
+	dosEPSMagic = []byte{0xC5, 0xD0, 0xD3, 0xC6}
+)
+
+// legacySection is the PostScript part of a legacy file
+type legacySection struct {
+	offset, length int64
+}
+
+// detectLegacy returns PostScript section of rs, nil if rs is not PostScript - rs is rewound either way
+func detectLegacy(rs io.ReadSeeker) (*legacySection, error) {
+	header := make([]byte, 30)
+	n, err := io.ReadFull(rs, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, errors.Wrap(err, "while reading header")
+	}
+	header = header[:n]
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "while rewinding")
+	}
+	switch {
+	case bytes.HasPrefix(header, postScriptMagic):
+		return &legacySection{0, -1}, nil
+	case bytes.HasPrefix(header, dosEPSMagic) && len(header) >= 12:
+		return &legacySection{
+			offset: int64(binary.LittleEndian.Uint32(header[4:8])),
+			length: int64(binary.LittleEndian.Uint32(header[8:12])),
+		}, nil
+	}
+	return nil, nil
+}
+
+// readLegacy returns nil if rs is not PostScript, ErrLegacyFormat if it is but conf does not allow it
+func readLegacy(ctx context.Context, rs io.ReadSeeker, conf *Configuration, lim *limiter) (*LegacyInfo, error) {
+	section, err := detectLegacy(rs)
+	if err != nil || section == nil {
+		return nil, errors.WithMessage(err, "while detecting legacy file")
+	}
+	if !conf.AllowLegacy {
+		return nil, ErrLegacyFormat
+	}
+	info, err := section.read(ctx, rs, lim)
+	return info, errors.WithMessage(err, "while reading legacy file")
+}
+
+// read loads the PostScript into memory - so that PrivateData outlives rs - and parses header comments
+func (section *legacySection) read(ctx context.Context, rs io.ReadSeeker, lim *limiter) (*LegacyInfo, error) {
+	if _, err := rs.Seek(section.offset, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "while seeking to PostScript")
+	}
+	var r io.Reader = rs
+	if section.length >= 0 {
+		r = io.LimitReader(rs, section.length)
+	}
+	data, err := io.ReadAll(&contextReader{ctx, &limitReader{l: lim, r: r}})
+	if err != nil {
+		return nil, errors.WithMessage(err, "while reading PostScript")
+	}
+	if !bytes.HasPrefix(data, postScriptMagic) {
+		return nil, newError(ErrNotIllustrator, errors.New("EPS section does not start with %!PS-Adobe"))
+	}
+
+	info := LegacyInfo{data: data}
+	var isIllustrator bool
+	var templateBox *pdfcpu.Rectangle
+	var artSize []float64
+	scanner := info.scanner(ctx)
+	for scanner.Scan() && !scanner.Continued() {
+		line := scanner.Text()
+		if line == "%%EndComments" || !strings.HasPrefix(line, "%") {
+			break
+		}
+		key, value := line, ""
+		if idx := strings.Index(line, ":"); idx >= 0 {
+			key, value = line[:idx], strings.TrimSpace(line[idx+1:])
+		}
+		switch key {
+		case "%%Creator":
+			info.Creator = value
+			isIllustrator = isIllustrator || strings.Contains(value, "Illustrator")
+		case "%%BoundingBox":
+			info.BoundingBox = legacyRect(value)
+		case "%%HiResBoundingBox":
+			info.HiResBoundingBox = legacyRect(value)
+		case "%AI3_TemplateBox":
+			templateBox = legacyRect(value)
+		case "%AI5_ArtSize":
+			artSize = legacyNumbers(value)
+		}
+		isIllustrator = isIllustrator || strings.HasPrefix(key, "%AI")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithMessage(err, "while reading header comments")
+	}
+	if !isIllustrator {
+		return nil, newError(ErrNotIllustrator, errors.New("PostScript has neither Illustrator creator nor %AI comments"))
+	}
+	if templateBox != nil && len(artSize) == 2 {
+		cx, cy := (templateBox.LL.X+templateBox.UR.X)/2, (templateBox.LL.Y+templateBox.UR.Y)/2
+		info.ArtboardBox = pdfcpu.Rect(cx-artSize[0]/2, cy-artSize[1]/2, cx+artSize[0]/2, cy+artSize[1]/2)
+	}
+	return &info, nil
+}
+
+// scanner splits data into lines, legacy files come with any of \r, \n or \r\n
+func (info *LegacyInfo) scanner(ctx context.Context) *lineReader {
+	return newLineReader(&contextReader{ctx, bytes.NewReader(info.data)}, true)
+}
+
+func (info *LegacyInfo) privateData(ctx context.Context) PrivateData {
+	return &closer{lineReader: info.scanner(ctx)}
+}
+
+// artboards returns the only artboard a legacy file has, falling back to bounding boxes if ArtboardBox is missing
+func (info *LegacyInfo) artboards() []Artboard {
+	artBox := info.HiResBoundingBox
+	if artBox == nil {
+		artBox = info.BoundingBox
+	}
+	mediaBox := info.ArtboardBox
+	if mediaBox == nil {
+		mediaBox = artBox
+	}
+	return []Artboard{{Name: "Artboard 1", MediaBox: mediaBox, CropBox: mediaBox, ArtBox: artBox}}
+}
+
+func legacyNumbers(value string) []float64 {
+	var numbers []float64
+	for _, field := range strings.Fields(value) {
+		number, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers
+}
+
+// legacyRect parses "llx lly urx ury", nil for anything else - including (atend)
+func legacyRect(value string) *pdfcpu.Rectangle {
+	numbers := legacyNumbers(value)
+	if len(numbers) != 4 {
+		return nil
+	}
+	return pdfcpu.Rect(numbers[0], numbers[1], numbers[2], numbers[3])
+}
@@ -0,0 +1,173 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// testLegacyFile returns testdata/legacy.ai, an Illustrator 8 file
+func testLegacyFile(t *testing.T) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile("testdata/legacy.ai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// testDOSEPS wraps ps into DOS EPS binary header, preview is a stand-in for the TIFF preview after PostScript
+func testDOSEPS(ps []byte) []byte {
+	header := make([]byte, 30)
+	copy(header, dosEPSMagic)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(header)))
+	binary.LittleEndian.PutUint32(header[8:12], uint32(len(ps)))
+	return append(append(header, ps...), "II*\x00 preview"...)
+}
+
+func TestDetectLegacy(t *testing.T) {
+	ps := testLegacyFile(t)
+	tests := []struct {
+		name    string
+		data    []byte
+		section *legacySection
+	}{
+		{"PostScript", ps, &legacySection{0, -1}},
+		{"DOS EPS", testDOSEPS(ps), &legacySection{30, int64(len(ps))}},
+		{"PDF", testFullPDF(), nil},
+		{"short", []byte("%!PS"), nil},
+		{"empty", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := bytes.NewReader(tt.data)
+			section, err := detectLegacy(rs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(section, tt.section) {
+				t.Errorf("got %+v, want %+v", section, tt.section)
+			}
+			if offset, _ := rs.Seek(0, 1); offset != 0 {
+				t.Errorf("left at offset %d", offset)
+			}
+		})
+	}
+}
+
+func TestReadLegacy(t *testing.T) {
+	ps := testLegacyFile(t)
+	want := &LegacyInfo{
+		Creator:          "Adobe Illustrator(R) 8.0",
+		BoundingBox:      pdfcpu.Rect(100, 200, 300, 400),
+		HiResBoundingBox: pdfcpu.Rect(100.5, 200.25, 299.75, 399.5),
+		// 612x792 artboard centred at %AI3_TemplateBox
+		ArtboardBox: pdfcpu.Rect(0, 0, 612, 792),
+	}
+	conf := testConfiguration()
+	conf.AllowLegacy = true
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{{"PostScript", ps}, {"DOS EPS", testDOSEPS(ps)}} {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := readLegacy(context.Background(), bytes.NewReader(tt.data), conf, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(info.data, ps) {
+				t.Errorf("got %d bytes of PostScript, want %d", len(info.data), len(ps))
+			}
+			info.data = nil
+			if !reflect.DeepEqual(info, want) {
+				t.Errorf("got %+v, want %+v", info, want)
+			}
+		})
+	}
+
+	info, err := readLegacy(context.Background(), bytes.NewReader(testFullPDF()), conf, nil)
+	if info != nil || err != nil {
+		t.Errorf("PDF got %+v, %v", info, err)
+	}
+}
+
+func TestReadLegacyErrors(t *testing.T) {
+	ps := testLegacyFile(t)
+	conf := testConfiguration()
+	if _, err := readLegacy(context.Background(), bytes.NewReader(ps), conf, nil); !errors.Is(err, ErrLegacyFormat) {
+		t.Errorf("not allowed got %v, want %v", err, ErrLegacyFormat)
+	}
+
+	conf.AllowLegacy = true
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"other PostScript", []byte("%!PS-Adobe-3.0\r%%Creator: dvips\r%%EndComments\r"), ErrNotIllustrator},
+		{"DOS EPS without PostScript", testDOSEPS([]byte("not PostScript")), ErrNotIllustrator},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readLegacy(context.Background(), bytes.NewReader(tt.data), conf, nil); !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	lim := newLimiter(Limits{MaxPrivateDataBytes: 100})
+	var limitErr *LimitError
+	if _, err := readLegacy(context.Background(), bytes.NewReader(ps), conf, lim); !errors.As(err, &limitErr) {
+		t.Errorf("got %v, want %s", err, LimitPrivateDataBytes)
+	}
+}
+
+func TestParseLegacy(t *testing.T) {
+	ps := testLegacyFile(t)
+	conf := testConfiguration()
+	conf.AllowLegacy = true
+	ctx := context.Background()
+	f, err := ParseContext(ctx, bytes.NewReader(ps), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Legacy == nil || f.Legacy.Creator != "Adobe Illustrator(R) 8.0" {
+		t.Fatalf("got legacy info %+v", f.Legacy)
+	}
+	lines := testReadLines(t, f.PrivateData)
+	if len(lines) != bytes.Count(ps, []byte("\r")) || lines[0] != "%!PS-Adobe-3.0 " || lines[len(lines)-1] != "%%EOF" {
+		t.Errorf("got %d lines of PostScript, from %q to %q", len(lines), lines[0], lines[len(lines)-1])
+	}
+
+	artboards, err := f.Artboards(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Artboard{{
+		Name:     "Artboard 1",
+		MediaBox: f.Legacy.ArtboardBox,
+		CropBox:  f.Legacy.ArtboardBox,
+		ArtBox:   f.Legacy.HiResBoundingBox,
+	}}
+	if !reflect.DeepEqual(artboards, want) {
+		t.Errorf("got %+v, want %+v", artboards, want)
+	}
+	// legacy files have no content streams
+	if _, err := f.Scene(1, nil, false); err == nil {
+		t.Error("got scene of legacy file")
+	}
+}
+
+func TestLegacyArtboardsFallBackToBoundingBox(t *testing.T) {
+	info := &LegacyInfo{BoundingBox: pdfcpu.Rect(0, 0, 10, 20)}
+	artboards := info.artboards()
+	if len(artboards) != 1 || artboards[0].MediaBox != info.BoundingBox || artboards[0].ArtBox != info.BoundingBox {
+		t.Errorf("got %+v", artboards)
+	}
+}
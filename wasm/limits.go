@@ -0,0 +1,321 @@
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// Limits bound resources spent on a single file, zero means unlimited
+type Limits struct {
+	// MaxPrivateDataBytes bounds decompressed private data
+	MaxPrivateDataBytes int64
+	// MaxImagePixels bounds Width * Height of a single image read via ImageReader
+	MaxImagePixels int64
+	// MaxStreamSize bounds decoded size of a single stream dict, checked during Parse - images are checked once read
+	MaxStreamSize int64
+	// MaxObjects bounds number of objects in xref table
+	MaxObjects int
+	// MaxAllocation bounds sum of decoded streams, decompressed private data and decoded image samples
+	MaxAllocation int64
+}
+
+type LimitKind string
+
+const (
+	LimitPrivateDataBytes LimitKind = "MaxPrivateDataBytes"
+	LimitImagePixels      LimitKind = "MaxImagePixels"
+	LimitStreamSize       LimitKind = "MaxStreamSize"
+	LimitObjects          LimitKind = "MaxObjects"
+	LimitAllocation       LimitKind = "MaxAllocation"
+)
+
+// LimitError is returned once any of Limits is exceeded, Value is the size reached when that was detected
+type LimitError struct {
+	Limit LimitKind
+	Max   int64
+	Value int64
+	// ObjNr is the object being processed, 0 if not applicable
+	ObjNr int
+}
+
+func (e *LimitError) Error() string {
+	if e.ObjNr != 0 {
+		return fmt.Sprintf("%s exceeded (obj#:%d): %d > %d", e.Limit, e.ObjNr, e.Value, e.Max)
+	}
+	return fmt.Sprintf("%s exceeded: %d > %d", e.Limit, e.Value, e.Max)
+}
+
+// limiter tracks Limits of a single file, it's shared between image readers and private data so it's guarded by mu
+type limiter struct {
+	limits Limits
+
+	mu        sync.Mutex
+	allocated int64
+	// charged holds size charged for each use of an object, so that reading it again does not count twice
+	charged map[charge]int64
+}
+
+// charge is a use of object ObjNr allocating memory
+type charge struct {
+	objNr int
+	use   chargeUse
+}
+
+type chargeUse int
+
+const (
+	chargeStream chargeUse = iota
+	chargeImage
+	chargePrivateData
+)
+
+func newLimiter(limits Limits) *limiter {
+	return &limiter{limits: limits, charged: map[charge]int64{}}
+}
+
+// allocate charges size for c, only growth beyond the size charged for c so far is allocated
+func (l *limiter) allocate(c charge, size int64) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prev, ok := l.charged[c]
+	if ok && size <= prev {
+		return nil
+	}
+	if max := l.limits.MaxAllocation; max > 0 {
+		if total := l.allocated + size - prev; total > max {
+			return &LimitError{Limit: LimitAllocation, Max: max, Value: total, ObjNr: c.objNr}
+		}
+	}
+	l.allocated += size - prev
+	l.charged[c] = size
+	return nil
+}
+
+// isCharged tells whether c was allocated already
+func (l *limiter) isCharged(c charge) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.charged[c]
+	return ok
+}
+
+// remaining returns how much can still be allocated, -1 if unlimited
+func (l *limiter) remaining() int64 {
+	if l == nil || l.limits.MaxAllocation <= 0 {
+		return -1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if left := l.limits.MaxAllocation - l.allocated; left > 0 {
+		return left
+	}
+	return 0
+}
+
+// maxPrivateDataBytes is Limits.MaxPrivateDataBytes, 0 if unlimited
+func (l *limiter) maxPrivateDataBytes() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.limits.MaxPrivateDataBytes
+}
+
+func (l *limiter) checkObjects(xRefTable *pdfcpu.XRefTable) error {
+	if l == nil || l.limits.MaxObjects <= 0 || len(xRefTable.Table) <= l.limits.MaxObjects {
+		return nil
+	}
+	return &LimitError{Limit: LimitObjects, Max: int64(l.limits.MaxObjects), Value: int64(len(xRefTable.Table))}
+}
+
+// checkStream measures decoded size of sd without keeping decoded content around, each object is measured once
+func (l *limiter) checkStream(objNr int, sd *pdfcpu.StreamDict) error {
+	if l == nil || (l.limits.MaxStreamSize <= 0 && l.limits.MaxAllocation <= 0) || l.isCharged(charge{objNr, chargeStream}) {
+		return nil
+	}
+	max := l.limits.MaxStreamSize
+	if left := l.remaining(); left >= 0 && (max <= 0 || left < max) {
+		max = left
+	}
+	size := decodedSize(sd, max)
+	if l.limits.MaxStreamSize > 0 && size > l.limits.MaxStreamSize {
+		return &LimitError{Limit: LimitStreamSize, Max: l.limits.MaxStreamSize, Value: size, ObjNr: objNr}
+	}
+	return l.allocate(charge{objNr, chargeStream}, size)
+}
+
+// checkImage charges decoded stream of the image same as checkStream and then its samples, which are decoded next
+func (l *limiter) checkImage(xRefTable *pdfcpu.XRefTable, objNr int, sd *pdfcpu.StreamDict) error {
+	if l == nil || (l.limits.MaxImagePixels <= 0 && l.limits.MaxStreamSize <= 0 && l.limits.MaxAllocation <= 0) {
+		return nil
+	}
+	width, height := int64(1), int64(1)
+	if val := sd.IntEntry("Width"); val != nil && *val > 0 {
+		width = int64(*val)
+	}
+	if val := sd.IntEntry("Height"); val != nil && *val > 0 {
+		height = int64(*val)
+	}
+	if pixels := width * height; l.limits.MaxImagePixels > 0 && pixels > l.limits.MaxImagePixels {
+		return &LimitError{Limit: LimitImagePixels, Max: l.limits.MaxImagePixels, Value: pixels, ObjNr: objNr}
+	}
+	if err := l.checkStream(objNr, sd); err != nil {
+		return err
+	}
+	// rows are padded to whole bytes
+	bits := int64(bitsPerPixel(xRefTable, sd))
+	return l.allocate(charge{objNr, chargeImage}, (width*bits+7)/8*height)
+}
+
+// bitsPerPixel returns BitsPerComponent times components of the image color space, 8 bits per component if missing.
+// Color spaces which can't be resolved count as 4 components, same as DeviceCMYK.
+func bitsPerPixel(xRefTable *pdfcpu.XRefTable, sd *pdfcpu.StreamDict) int {
+	if mask := sd.BooleanEntry("ImageMask"); mask != nil && *mask {
+		return 1
+	}
+	bpc := 8
+	if val := sd.IntEntry("BitsPerComponent"); val != nil && *val > 0 {
+		bpc = *val
+	}
+	return bpc * colorComponents(xRefTable, sd.Dict["ColorSpace"])
+}
+
+// colorComponents returns the number of components of color space cs
+func colorComponents(xRefTable *pdfcpu.XRefTable, cs pdfcpu.Object) int {
+	cs = dereference(xRefTable, cs)
+	var name string
+	switch cs := cs.(type) {
+	case pdfcpu.Name:
+		name = cs.Value()
+	case pdfcpu.Array:
+		if len(cs) == 0 {
+			return 4
+		}
+		if n, ok := cs[0].(pdfcpu.Name); ok {
+			name = n.Value()
+		}
+		switch name {
+		case "ICCBased":
+			if len(cs) > 1 {
+				if sd, ok := dereference(xRefTable, cs[1]).(pdfcpu.StreamDict); ok {
+					if n := sd.IntEntry("N"); n != nil && *n > 0 {
+						return *n
+					}
+				}
+			}
+			return 4
+		case "DeviceN":
+			if len(cs) > 1 {
+				if names, ok := dereference(xRefTable, cs[1]).(pdfcpu.Array); ok && len(names) > 0 {
+					return len(names)
+				}
+			}
+			return 4
+		}
+	}
+	switch name {
+	case "DeviceGray", "G", "CalGray", "Indexed", "I", "Separation":
+		return 1
+	case "DeviceRGB", "RGB", "CalRGB", "Lab":
+		return 3
+	}
+	return 4
+}
+
+// dereference resolves obj if it's an indirect reference, nil if that fails
+func dereference(xRefTable *pdfcpu.XRefTable, obj pdfcpu.Object) pdfcpu.Object {
+	if _, ok := obj.(pdfcpu.IndirectRef); !ok {
+		return obj
+	}
+	if xRefTable == nil {
+		return nil
+	}
+	obj, err := xRefTable.Dereference(obj)
+	if err != nil {
+		return nil
+	}
+	return obj
+}
+
+// decodedSize returns the size of decoded sd, bounded by max+1 unless max < 0 - errors are left for Decode to report.
+// pdfcpu filters decode a stage at a time, so decoding stops after the first stage exceeding max. Image codecs
+// (DCTDecode, CCITTFaxDecode, JBIG2Decode and JPXDecode) are not run, their input size is returned - output of those is
+// bounded by the image dimensions, see checkImage.
+func decodedSize(sd *pdfcpu.StreamDict, max int64) int64 {
+	size := int64(len(sd.Raw))
+	if sd.Content != nil {
+		size = int64(len(sd.Content))
+	} else {
+		var r io.Reader = bytes.NewReader(sd.Raw)
+		for _, f := range sd.FilterPipeline {
+			if isImageCodec(f.Name) || (max >= 0 && size > max) {
+				break
+			}
+			fi, err := filter.NewFilter(f.Name, filterParms(sd, f))
+			if err != nil {
+				break
+			}
+			if r, err = fi.Decode(r); err != nil {
+				break
+			}
+			size, r = stageSize(r, max)
+		}
+	}
+	if max >= 0 && size > max {
+		return max + 1
+	}
+	return size
+}
+
+func isImageCodec(name string) bool {
+	switch name {
+	case filter.DCT, filter.CCITTFax, filter.JBIG2, filter.JPX:
+		return true
+	}
+	return false
+}
+
+// stageSize returns the size of r decoded by a filter stage, along with r to read it again. pdfcpu filters return
+// buffers, anything else is buffered up to max+1.
+func stageSize(r io.Reader, max int64) (int64, io.Reader) {
+	if buf, ok := r.(interface{ Len() int }); ok {
+		return int64(buf.Len()), r
+	}
+	if max >= 0 {
+		r = io.LimitReader(r, max+1)
+	}
+	var buf bytes.Buffer
+	n, _ := io.Copy(&buf, r)
+	return n, &buf
+}
+
+// limitReader fails with LimitError once more than MaxPrivateDataBytes is read. Private data of objNr is charged once,
+// reading it again only allocates beyond what was read before.
+type limitReader struct {
+	l     *limiter
+	r     io.Reader
+	objNr int
+	read  int64
+}
+
+func (lr *limitReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	if lr.l == nil {
+		return n, err
+	}
+	lr.read += int64(n)
+	if max := lr.l.maxPrivateDataBytes(); max > 0 && lr.read > max {
+		return n, &LimitError{Limit: LimitPrivateDataBytes, Max: max, Value: lr.read, ObjNr: lr.objNr}
+	}
+	if err := lr.l.allocate(charge{lr.objNr, chargePrivateData}, lr.read); err != nil {
+		return n, err
+	}
+	return n, err
+}
@@ -0,0 +1,187 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// testEncodedStream returns stream dict of data encoded by filters, the last one being applied first
+func testEncodedStream(t *testing.T, dict pdfcpu.Dict, data []byte, filters ...string) *pdfcpu.StreamDict {
+	t.Helper()
+	sd := pdfcpu.StreamDict{Dict: dict, Raw: data}
+	for idx := len(filters) - 1; idx >= 0; idx-- {
+		f, err := filter.NewFilter(filters[idx], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := f.Encode(bytes.NewReader(sd.Raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sd.Raw, err = io.ReadAll(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range filters {
+		sd.FilterPipeline = append(sd.FilterPipeline, pdfcpu.PDFFilter{Name: name})
+	}
+	return &sd
+}
+
+func TestDecodedSize(t *testing.T) {
+	data := append(bytes.Repeat([]byte{0}, 5000), []byte("some text which does not repeat itself 0123456789")...)
+	tests := []struct {
+		name    string
+		filters []string
+	}{
+		{"none", nil},
+		{"Flate", []string{filter.Flate}},
+		{"LZW", []string{filter.LZW}},
+		{"ASCII85", []string{filter.ASCII85}},
+		{"ASCIIHex", []string{filter.ASCIIHex}},
+		{"RunLength", []string{filter.RunLength}},
+		{"ASCII85 Flate", []string{filter.ASCII85, filter.Flate}},
+		{"ASCIIHex RunLength", []string{filter.ASCIIHex, filter.RunLength}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sd := testEncodedStream(t, nil, data, tt.filters...)
+			if size := decodedSize(sd, -1); size != int64(len(data)) {
+				t.Errorf("got %d, expected %d", size, len(data))
+			}
+			if size := decodedSize(sd, 100); size != 101 {
+				t.Errorf("bounded by 100 got %d, expected 101", size)
+			}
+			if err := sd.Decode(); err != nil || !bytes.Equal(sd.Content, data) {
+				t.Errorf("stream does not decode to data: %v", err)
+			}
+		})
+	}
+}
+
+func TestLimiterChargesOnce(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	lim := newLimiter(Limits{MaxAllocation: 1500, MaxStreamSize: 1200})
+	sd := testEncodedStream(t, nil, data, filter.Flate)
+	for run := 0; run < 3; run++ {
+		if err := lim.checkStream(7, sd); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+	}
+	var limitErr *LimitError
+	if err := lim.checkStream(8, sd); !errors.As(err, &limitErr) || limitErr.Limit != LimitAllocation || limitErr.ObjNr != 8 {
+		t.Errorf("another object got %v, expected %s", err, LimitAllocation)
+	}
+
+	lim = newLimiter(Limits{MaxAllocation: 1500})
+	dc := decompressor{}
+	dc.add(append(bytes.Repeat([]byte("line\r"), 199), "line"...))
+	for run := 0; run < 3; run++ {
+		pd, err := dc.process(context.Background(), lim, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := 0
+		for pd.Scan() {
+			lines++
+		}
+		if err := pd.Err(); err != nil || lines != 200 {
+			t.Fatalf("run %d read %d lines: %v", run, lines, err)
+		}
+		pd.Close()
+	}
+}
+
+func TestImageLimits(t *testing.T) {
+	dict := pdfcpu.Dict{"Width": pdfcpu.Integer(10), "Height": pdfcpu.Integer(10)}
+	bomb := testEncodedStream(t, dict, make([]byte, 1<<20), filter.Flate)
+	tests := []struct {
+		name   string
+		limits Limits
+		sd     *pdfcpu.StreamDict
+		limit  LimitKind
+	}{
+		{"pixels", Limits{MaxImagePixels: 99}, bomb, LimitImagePixels},
+		{"decoded stream", Limits{MaxStreamSize: 1 << 19}, bomb, LimitStreamSize},
+		{"allocation", Limits{MaxAllocation: 1 << 20}, bomb, LimitAllocation},
+		{"within limits", Limits{MaxImagePixels: 100, MaxStreamSize: 1 << 20, MaxAllocation: 1<<20 + 400}, bomb, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lim := newLimiter(tt.limits)
+			err := lim.checkImage(nil, 3, tt.sd)
+			var limitErr *LimitError
+			if tt.limit == "" {
+				if err != nil {
+					t.Errorf("unexpected %v", err)
+				}
+				if err := lim.checkImage(nil, 3, tt.sd); err != nil {
+					t.Errorf("second check got %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &limitErr) || limitErr.Limit != tt.limit {
+				t.Errorf("got %v, expected %s", err, tt.limit)
+			}
+		})
+	}
+}
+
+func TestImageSamplesCharged(t *testing.T) {
+	iccProfile := pdfcpu.StreamDict{Dict: pdfcpu.Dict{"N": pdfcpu.Integer(3)}}
+	xRefTable := &pdfcpu.XRefTable{Table: map[int]*pdfcpu.XRefTableEntry{5: pdfcpu.NewXRefTableEntryGen0(iccProfile)}}
+	tests := []struct {
+		name string
+		dict pdfcpu.Dict
+		size int64
+	}{
+		{"gray", pdfcpu.Dict{"ColorSpace": pdfcpu.Name("DeviceGray"), "BitsPerComponent": pdfcpu.Integer(8)}, 100},
+		{"rgb", pdfcpu.Dict{"ColorSpace": pdfcpu.Name("DeviceRGB"), "BitsPerComponent": pdfcpu.Integer(8)}, 300},
+		{"cmyk 16 bits", pdfcpu.Dict{"ColorSpace": pdfcpu.Name("DeviceCMYK"), "BitsPerComponent": pdfcpu.Integer(16)}, 800},
+		{"rows padded", pdfcpu.Dict{"ColorSpace": pdfcpu.Name("DeviceGray"), "BitsPerComponent": pdfcpu.Integer(1)}, 20},
+		{"mask", pdfcpu.Dict{"ImageMask": pdfcpu.Boolean(true)}, 20},
+		{"indexed", pdfcpu.Dict{"ColorSpace": pdfcpu.Array{pdfcpu.Name("Indexed"), pdfcpu.Name("DeviceRGB"), pdfcpu.Integer(1), pdfcpu.StringLiteral("")}, "BitsPerComponent": pdfcpu.Integer(4)}, 50},
+		{"icc", pdfcpu.Dict{"ColorSpace": pdfcpu.Array{pdfcpu.Name("ICCBased"), *pdfcpu.NewIndirectRef(5, 0)}}, 300},
+		{"devicen", pdfcpu.Dict{"ColorSpace": pdfcpu.Array{pdfcpu.Name("DeviceN"), pdfcpu.Array{pdfcpu.Name("Spot"), pdfcpu.Name("Black")}}}, 200},
+		{"unresolved", pdfcpu.Dict{"ColorSpace": *pdfcpu.NewIndirectRef(9, 0)}, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.dict["Width"], tt.dict["Height"] = pdfcpu.Integer(10), pdfcpu.Integer(10)
+			sd := pdfcpu.StreamDict{Dict: tt.dict}
+			lim := newLimiter(Limits{MaxAllocation: 1 << 20})
+			if err := lim.checkImage(xRefTable, 3, &sd); err != nil {
+				t.Fatal(err)
+			}
+			if size := lim.charged[charge{3, chargeImage}]; size != tt.size {
+				t.Errorf("charged %d, want %d", size, tt.size)
+			}
+		})
+	}
+}
+
+func TestDecodedSizeStopsAtImageCodec(t *testing.T) {
+	jpeg := []byte("\xff\xd8 not really a jpeg")
+	sd := testEncodedStream(t, nil, jpeg, filter.Flate)
+	sd.FilterPipeline = append(sd.FilterPipeline, pdfcpu.PDFFilter{Name: filter.DCT})
+	if size := decodedSize(sd, -1); size != int64(len(jpeg)) {
+		t.Errorf("got %d, want input size of DCTDecode %d", size, len(jpeg))
+	}
+}
+
+func TestUnlimitedReader(t *testing.T) {
+	var lim *limiter
+	data, err := io.ReadAll(&limitReader{l: lim, r: bytes.NewReader([]byte("private data"))})
+	if err != nil || string(data) != "private data" {
+		t.Errorf("got %q, %v", data, err)
+	}
+	if max := lim.maxPrivateDataBytes(); max != 0 {
+		t.Errorf("got limit %d without limiter", max)
+	}
+}
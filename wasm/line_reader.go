@@ -0,0 +1,138 @@
+package wasm
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// lineReader splits r into lines the way bufio.Scanner would, except lines longer than its buffer do not fail -
+// they are returned in parts of buffer size instead, all but the last one flagged by Continued.
+// Private data lines are terminated by \r, anyEOL makes \n and \r\n terminators as well, which is the case of legacy files.
+type lineReader struct {
+	r      io.Reader
+	anyEOL bool
+
+	buf        []byte
+	start, end int
+	token      []byte
+	continued  bool
+	terminated bool
+	// skipLF is set when \r ended the buffer with anyEOL, so that \n of \r\n is not taken for an empty line
+	skipLF bool
+	err    error
+}
+
+// maxEmptyReads is the number of reads returning nothing before giving up, same as in bufio.Scanner
+const maxEmptyReads = 100
+
+func newLineReader(r io.Reader, anyEOL bool) *lineReader {
+	return &lineReader{r: r, anyEOL: anyEOL, buf: make([]byte, BufferSize)}
+}
+
+// Scan advances to the next line or its part, which will then be available through the Bytes method.
+func (lr *lineReader) Scan() bool {
+	for {
+		if lr.skipLF && lr.start < lr.end {
+			if lr.buf[lr.start] == '\n' {
+				lr.start++
+			}
+			lr.skipLF = false
+		}
+		data := lr.buf[lr.start:lr.end]
+		idx := bytes.IndexByte(data, '\r')
+		if lr.anyEOL {
+			idx = bytes.IndexAny(data, "\r\n")
+		}
+		if idx >= 0 {
+			lr.emit(idx, false)
+			lr.terminated = true
+			lr.start++ // terminator
+			if lr.anyEOL && data[idx] == '\r' {
+				if lr.start < lr.end {
+					if lr.buf[lr.start] == '\n' {
+						lr.start++
+					}
+				} else {
+					lr.skipLF = true
+				}
+			}
+			return true
+		}
+		if lr.err != nil {
+			if len(data) > 0 {
+				lr.emit(len(data), false)
+				return true
+			}
+			lr.token = nil
+			lr.continued = false
+			return false
+		}
+		if len(data) == len(lr.buf) {
+			lr.emit(len(data), true)
+			return true
+		}
+		lr.fill()
+	}
+}
+
+func (lr *lineReader) emit(n int, continued bool) {
+	lr.token = lr.buf[lr.start : lr.start+n]
+	lr.start += n
+	lr.continued = continued
+	lr.terminated = false
+}
+
+// fill moves unread data to the front of the buffer and reads more after it
+func (lr *lineReader) fill() {
+	if lr.start > 0 {
+		lr.end = copy(lr.buf, lr.buf[lr.start:lr.end])
+		lr.start = 0
+	}
+	for i := 0; i < maxEmptyReads; i++ {
+		n, err := lr.r.Read(lr.buf[lr.end:])
+		lr.end += n
+		if err != nil {
+			lr.err = err
+			return
+		}
+		if n > 0 {
+			return
+		}
+	}
+	lr.err = io.ErrNoProgress
+}
+
+// Bytes returns the most recent token generated by a call to Scan, without terminator.
+// The underlying array may point to data that will be overwritten by a subsequent call to Scan.
+func (lr *lineReader) Bytes() []byte {
+	return lr.token
+}
+
+// Text returns the most recent token as string
+func (lr *lineReader) Text() string {
+	return string(lr.token)
+}
+
+// Continued tells whether the line goes on in the next token, i.e. it was longer than BufferSize
+func (lr *lineReader) Continued() bool {
+	return lr.continued
+}
+
+// Terminated tells whether the most recent token was ended by a line terminator - it's not for parts of long lines
+// and for the last line of data which lacks one
+func (lr *lineReader) Terminated() bool {
+	return lr.terminated
+}
+
+// Err returns the first non-EOF error, premature end of compressed stream is reported as ErrTruncatedPrivateData
+func (lr *lineReader) Err() error {
+	if lr.err == io.EOF {
+		return nil
+	}
+	if errors.Is(lr.err, io.ErrUnexpectedEOF) {
+		return newError(ErrTruncatedPrivateData, lr.err)
+	}
+	return lr.err
+}
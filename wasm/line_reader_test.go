@@ -0,0 +1,70 @@
+package wasm
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type testLine struct {
+	text      string
+	continued bool
+}
+
+func TestLineReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		anyEOL   bool
+		bufSize  int
+		expected []testLine
+	}{
+		{"carriage returns", "a\rbc\r\rd", false, 16, []testLine{{"a", false}, {"bc", false}, {"", false}, {"d", false}}},
+		{"line feeds are kept", "a\nb\rc\r", false, 16, []testLine{{"a\nb", false}, {"c", false}}},
+		{"any line ending", "a\r\nb\nc\rd\n", true, 16, []testLine{{"a", false}, {"b", false}, {"c", false}, {"d", false}}},
+		{"empty lines of any ending", "a\n\r\n\rb", true, 16, []testLine{{"a", false}, {"", false}, {"", false}, {"b", false}}},
+		{"\\r\\n split by buffer", "ab\r\ncd", true, 4, []testLine{{"ab", false}, {"cd", false}}},
+		{"long lines", "abcdefghij\rk", false, 4, []testLine{{"abcd", true}, {"efgh", true}, {"ij", false}, {"k", false}}},
+		{"line of buffer size", "abcd\ref", false, 4, []testLine{{"abcd", true}, {"", false}, {"ef", false}}},
+		{"empty", "", false, 4, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// one byte reads move terminators to every possible position within the buffer
+			r := iotest.OneByteReader(strings.NewReader(tt.data))
+			lr := &lineReader{r: r, anyEOL: tt.anyEOL, buf: make([]byte, tt.bufSize)}
+			var got []testLine
+			for lr.Scan() {
+				got = append(got, testLine{lr.Text(), lr.Continued()})
+			}
+			if err := lr.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %+v, expected %+v", got, tt.expected)
+			}
+			for idx := range got {
+				if got[idx] != tt.expected[idx] {
+					t.Errorf("line %d got %+v, expected %+v", idx, got[idx], tt.expected[idx])
+				}
+			}
+		})
+	}
+}
+
+func TestLineReaderTruncated(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("a\rb"), iotest.ErrReader(io.ErrUnexpectedEOF))
+	lr := newLineReader(r, false)
+	var lines []string
+	for lr.Scan() {
+		lines = append(lines, lr.Text())
+	}
+	if len(lines) != 2 || lines[1] != "b" {
+		t.Errorf("got %q", lines)
+	}
+	if err := lr.Err(); !errors.Is(err, ErrTruncatedPrivateData) {
+		t.Errorf("got %v, expected ErrTruncatedPrivateData", err)
+	}
+}
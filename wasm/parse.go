@@ -1,9 +1,10 @@
 package wasm
 
 import (
-	"fmt"
+	"context"
 	"io"
 	"os"
+	"sync"
 
 	"github.com/pdfcpu/pdfcpu/pkg/api"
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
@@ -11,79 +12,167 @@ import (
 )
 
 type IllustratorFile struct {
-	PrivateData    PrivateData
+	// PrivateData is the first of PrivateStreams, opened
+	PrivateData PrivateData
+	// PrivateStreams lists every distinct private dict together with pages referring to it
+	PrivateStreams []PrivateStream
+	// PrivateDataAbsent is the reason why PrivateData is nil despite being requested, see Configuration.AllowMissingPrivateData
+	PrivateDataAbsent error
+	// Diagnostics hold every strict validation problem tolerated thanks to Configuration.ValidationPolicy, up to 32 of
+	// them, malformed XMP metadata and malformed PieceInfo of pages when private data is found elsewhere
+	Diagnostics []Diagnostic
+	// Legacy is set for pre-CS PostScript files, which have nothing but PrivateData and artboard, see Configuration.AllowLegacy
+	Legacy *LegacyInfo
+	// XMP is parsed from the catalog Metadata stream, nil if there is none or it is malformed - see Diagnostics
+	XMP            *XMP
 	SerializedFile *SerializedFile
 	Bitmaps        Bitmaps
 	Fonts          Fonts
 	StreamDicts    StreamDicts
+
+	// indexedMu guards indexed, artboardSetupsMu guards artboard setups - both are read on the first use and cached
+	indexedMu          sync.Mutex
+	indexed            *IndexedPrivateData
+	artboardSetupsMu   sync.Mutex
+	artboardSetups     []*ArtboardSetup
+	artboardSetupsRead bool
 }
 
 type Configuration struct {
 	pdfcpu.Configuration
 	// PrivateData must be explicitly closed if requested, otherwise decompress will leak memory
 	WithPrivateData bool
+	// SkipFonts leaves IllustratorFile.Fonts empty
+	SkipFonts bool
+	// SkipBitmaps leaves IllustratorFile.Bitmaps empty, image XObjects are not added to StreamDicts either
+	SkipBitmaps bool
+	// SkipOptimize skips api.OptimizeContext - fonts are found during optimization, so it requires SkipFonts
+	SkipOptimize bool
+	// SkipSerialization leaves IllustratorFile.SerializedFile nil, which also rules out Artboards and Scene
+	SkipSerialization bool
+	// PrivateDataOnly stops right after private data extraction, skipping validation - implies WithPrivateData
+	PrivateDataOnly bool
+	// AllowMissingPrivateData makes Parse treat file without PieceInfo->Illustrator->Private as a plain PDF -
+	// e.g. saved without "Preserve Illustrator Editing Capabilities", instead of failing with ErrMissingPieceInfo
+	AllowMissingPrivateData bool
+	// AllowLegacy makes Parse read pre-CS PostScript files (AI 8 to 10) - only PrivateData and Artboards are available then,
+	// otherwise such files fail with ErrLegacyFormat
+	AllowLegacy bool
+	// ValidationPolicy decides whether failed validation is retried in relaxed mode, see Diagnostics
+	ValidationPolicy ValidationPolicy
+	// Limits bound resources spent on untrusted files, LimitError is returned once any is exceeded
+	Limits Limits
 }
 
 func Parse(rs io.ReadSeeker, conf *Configuration) (*IllustratorFile, error) {
+	return ParseContext(context.Background(), rs, conf)
+}
+
+// ParseContext is Parse which stops once ctx is done - whilst reading the file, between the stages and stream dicts,
+// whilst decompressing private data and extracting fonts. Validation and optimization run to completion once started.
+// Returned PrivateData stops reading with ctx.Err() as well.
+func ParseContext(ctx context.Context, rs io.ReadSeeker, conf *Configuration) (*IllustratorFile, error) {
+	if conf.SkipOptimize && !conf.SkipFonts {
+		return nil, errors.New("fonts are extracted during optimization, SkipOptimize requires SkipFonts")
+	}
+
 	var ret IllustratorFile
 	var s Stats
 	s.Observe("start")
 	defer s.Report()
 
-	ctx, err := api.ReadContext(rs, &conf.Configuration)
+	lim := newLimiter(conf.Limits)
+	legacy, err := readLegacy(ctx, rs, conf, lim)
+	if err != nil {
+		return nil, err
+	}
+	if legacy != nil {
+		ret.Legacy = legacy
+		if conf.WithPrivateData || conf.PrivateDataOnly {
+			ret.PrivateData = legacy.privateData(ctx)
+		}
+		s.Observe("legacy")
+		return &ret, nil
+	}
+
+	pdfCtx, err := readContext(ctx, rs, &conf.Configuration)
 	if err != nil {
 		return &ret, errors.WithMessage(err, "while opening read context")
 	}
 	s.Observe("read")
 
-	if err = api.ValidateContext(ctx); err != nil {
-		s := ""
-		if conf.ValidationMode == pdfcpu.ValidationStrict {
-			s = " (try -mode=relaxed)"
+	if err := lim.checkObjects(pdfCtx.XRefTable); err != nil {
+		return nil, err
+	}
+
+	if conf.PrivateDataOnly {
+		ret.PrivateData, ret.PrivateStreams, err = extractPrivateData(ctx, pdfCtx, lim, &ret.Diagnostics)
+		s.Observe("private data")
+		if err != nil {
+			return nil, errors.WithMessage(err, "whilst extracting private data")
 		}
-		err = errors.Wrap(err, fmt.Sprintf("validation error (obj#:%d)%s", ctx.CurObj, s))
+		return &ret, nil
 	}
-	if err != nil {
+
+	if ret.Diagnostics, err = validate(ctx, pdfCtx, conf); err != nil {
 		return nil, errors.WithMessage(err, "whilst validating")
 	}
 	s.Observe("validate")
 
+	ret.XMP = extractXMP(pdfCtx.XRefTable, &ret.Diagnostics)
+	s.Observe("xmp")
+
 	if conf.WithPrivateData {
-		ret.PrivateData, err = extractPrivateData(ctx)
+		ret.PrivateData, ret.PrivateStreams, err = extractPrivateData(ctx, pdfCtx, lim, &ret.Diagnostics)
 		s.Observe("private data")
+		if conf.AllowMissingPrivateData && errors.Is(err, ErrMissingPieceInfo) {
+			ret.PrivateDataAbsent, err = err, nil
+		}
 	}
 
 	if err != nil {
 		return nil, errors.WithMessage(err, "whilst extracting private data")
 	}
 
-	ret.StreamDicts, ret.Bitmaps, err = extractStreamDicts(ctx)
+	ret.StreamDicts, ret.Bitmaps, err = extractStreamDicts(ctx, pdfCtx, !conf.SkipBitmaps, lim)
 	s.Observe("extract stream dicts")
 
 	if err != nil {
 		return nil, errors.WithMessage(err, "whilst extracting stream dicts")
 	}
 
-	// NOTE: breaks parsing private data because it removes Illustrator comments - it has to happen _after_ private data extraction
-	err = api.OptimizeContext(ctx)
-	s.Observe("optimize")
+	if !conf.SkipOptimize {
+		// NOTE: breaks parsing private data because it removes Illustrator comments - it has to happen _after_ private data extraction
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		err = api.OptimizeContext(pdfCtx)
+		s.Observe("optimize")
 
-	if err != nil {
-		return nil, errors.WithMessage(err, "whilst opening optimization context")
+		if err != nil {
+			return nil, errors.WithMessage(err, "whilst opening optimization context")
+		}
 	}
 
-	ret.Fonts, err = extractFonts(ctx)
-	s.Observe("extract fonts")
+	if !conf.SkipFonts {
+		ret.Fonts, err = extractFonts(ctx, pdfCtx)
+		s.Observe("extract fonts")
 
-	if err != nil {
-		return nil, errors.WithMessage(err, "whilst extracting fonts")
+		if err != nil {
+			return nil, errors.WithMessage(err, "whilst extracting fonts")
+		}
 	}
 
-	ret.SerializedFile, err = serialize(ctx)
-	s.Observe("serialize")
+	if !conf.SkipSerialization {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ret.SerializedFile, err = serialize(pdfCtx)
+		s.Observe("serialize")
 
-	if err != nil {
-		return nil, errors.WithMessage(err, "whilst serializing final structure")
+		if err != nil {
+			return nil, errors.WithMessage(err, "whilst serializing final structure")
+		}
 	}
 
 	return &ret, err
@@ -93,11 +182,15 @@ func NewConfiguration() *Configuration {
 	pdfcpu.ConfigPath = "disable"
 	api.DisableConfigDir()
 
-	conf := Configuration{*pdfcpu.NewDefaultConfiguration(), false}
+	conf := Configuration{Configuration: *pdfcpu.NewDefaultConfiguration()}
 	return &conf
 }
 
 func ParseFile(inFile string, conf *Configuration) (*IllustratorFile, error) {
+	return ParseFileContext(context.Background(), inFile, conf)
+}
+
+func ParseFileContext(ctx context.Context, inFile string, conf *Configuration) (*IllustratorFile, error) {
 	f, err := os.Open(inFile)
 	if err != nil {
 		return nil, err
@@ -105,7 +198,7 @@ func ParseFile(inFile string, conf *Configuration) (*IllustratorFile, error) {
 
 	defer f.Close()
 
-	ret, err := Parse(f, conf)
+	ret, err := ParseContext(ctx, f, conf)
 	if err != nil {
 		return nil, err
 	}
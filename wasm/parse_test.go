@@ -0,0 +1,172 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// testFullPDF returns testIllustratorPDF drawing an embedded TrueType font (obj 9) and an image (obj 12)
+func testFullPDF() []byte {
+	return testPDF(testFullObjects()...)
+}
+
+// testFullObjects returns objects of testFullPDF
+func testFullObjects() []string {
+	return testIllustratorObjects([]string{"%AI5_BeginLayer", "%AI5_EndLayer--"}, "0 0 100 100",
+		"/Font << /F1 9 0 R >> /XObject << /Im0 12 0 R >>",
+		"BT /F1 12 Tf (A) Tj ET q 10 0 0 10 0 0 cm /Im0 Do Q",
+		"<< /Type /Font /Subtype /TrueType /BaseFont /ABCDEF+Test /FirstChar 65 /LastChar 65 /Widths [500] /FontDescriptor 10 0 R >>",
+		"<< /Type /FontDescriptor /FontName /ABCDEF+Test /Flags 32 /FontBBox [0 0 1000 1000] /ItalicAngle 0 "+
+			"/Ascent 800 /Descent -200 /CapHeight 700 /StemV 80 /FontFile2 11 0 R >>",
+		testStream("/Length1 4", "true"),
+		testStream("/Type /XObject /Subtype /Image /Width 1 /Height 1 /ColorSpace /DeviceGray /BitsPerComponent 8", "\x80"),
+	)
+}
+
+func TestSkipOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		configure  func(conf *Configuration)
+		fonts      int
+		bitmaps    int
+		serialized bool
+		streams    bool
+	}{
+		{"nothing skipped", func(*Configuration) {}, 1, 1, true, true},
+		{"SkipFonts", func(conf *Configuration) { conf.SkipFonts = true }, 0, 1, true, true},
+		{"SkipBitmaps", func(conf *Configuration) { conf.SkipBitmaps = true }, 1, 0, true, true},
+		{"SkipOptimize", func(conf *Configuration) { conf.SkipOptimize, conf.SkipFonts = true, true }, 0, 1, true, true},
+		{"SkipSerialization", func(conf *Configuration) { conf.SkipSerialization = true }, 1, 1, false, true},
+		{"PrivateDataOnly", func(conf *Configuration) { conf.PrivateDataOnly = true }, 0, 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			conf := testConfiguration()
+			tt.configure(conf)
+			f, err := ParseContext(ctx, bytes.NewReader(testFullPDF()), conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.PrivateData.Close()
+			if len(f.Fonts) != tt.fonts || len(f.Bitmaps) != tt.bitmaps {
+				t.Errorf("got %d fonts and %d bitmaps, expected %d and %d", len(f.Fonts), len(f.Bitmaps), tt.fonts, tt.bitmaps)
+			}
+			if _, ok := f.StreamDicts[12]; ok {
+				t.Errorf("image is in StreamDicts")
+			}
+			if _, ok := f.StreamDicts[8]; ok != tt.streams {
+				t.Errorf("content stream in StreamDicts is %v, expected %v", ok, tt.streams)
+			}
+			if (f.SerializedFile != nil) != tt.serialized {
+				t.Fatalf("got SerializedFile %v", f.SerializedFile != nil)
+			}
+			// the same calls fail without SerializedFile instead of panicking
+			_, sceneErr := f.Scene(8, nil, false)
+			_, artboardsErr := f.Artboards(ctx)
+			if tt.serialized != (sceneErr == nil) || tt.serialized != (artboardsErr == nil) {
+				t.Errorf("got Scene error %v and Artboards error %v", sceneErr, artboardsErr)
+			}
+			if _, err := f.Symbols(ctx); err != nil {
+				t.Errorf("Symbols got %v", err)
+			}
+			if _, err := f.PlacedItems(ctx); err != nil {
+				t.Errorf("PlacedItems got %v", err)
+			}
+			if _, err := f.xObjectUses(ctx, "Image"); tt.serialized != (err == nil) {
+				t.Errorf("xObjectUses got %v", err)
+			}
+		})
+	}
+
+	conf := testConfiguration()
+	conf.SkipOptimize = true
+	if _, err := ParseContext(context.Background(), bytes.NewReader(testFullPDF()), conf); err == nil {
+		t.Errorf("SkipOptimize without SkipFonts parsed")
+	}
+}
+
+// testPlainPDF returns testFullPDF saved without Illustrator editing capabilities, i.e. without PieceInfo of the page.
+// The catalog gets the PieceInfo if onCatalog.
+func testPlainPDF(onCatalog bool) []byte {
+	objects := testFullObjects()
+	pieceInfo := " /PieceInfo << /Illustrator 4 0 R >>"
+	objects[2] = strings.Replace(objects[2], pieceInfo, "", 1)
+	if onCatalog {
+		objects[0] = strings.Replace(objects[0], " >>", pieceInfo+" >>", 1)
+	}
+	return testPDF(objects...)
+}
+
+func TestAllowMissingPrivateData(t *testing.T) {
+	ctx := context.Background()
+	conf := testConfiguration()
+	if _, err := ParseContext(ctx, bytes.NewReader(testPlainPDF(false)), conf); !errors.Is(err, ErrMissingPieceInfo) {
+		t.Errorf("got %v, want %v", err, ErrMissingPieceInfo)
+	}
+
+	conf.AllowMissingPrivateData = true
+	f, err := ParseContext(ctx, bytes.NewReader(testPlainPDF(false)), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.PrivateData != nil || !errors.Is(f.PrivateDataAbsent, ErrMissingPieceInfo) {
+		t.Errorf("got private data %v, absent because of %v", f.PrivateData, f.PrivateDataAbsent)
+	}
+	artboards, err := f.Artboards(ctx)
+	if err != nil || len(artboards) != 1 {
+		t.Errorf("got artboards %+v, %v", artboards, err)
+	}
+	if len(f.StreamDicts) == 0 || len(f.Bitmaps) != 1 || len(f.Fonts) == 0 {
+		t.Errorf("got %d stream dicts, %d bitmaps and %d fonts", len(f.StreamDicts), len(f.Bitmaps), len(f.Fonts))
+	}
+
+	doc, err := ParseLazyContext(ctx, bytes.NewReader(testPlainPDF(false)), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.PrivateData != nil || !errors.Is(doc.PrivateDataAbsent, ErrMissingPieceInfo) {
+		t.Errorf("lazy got private data %v, absent because of %v", doc.PrivateData, doc.PrivateDataAbsent)
+	}
+}
+
+func TestAllowMissingPrivateDataFallback(t *testing.T) {
+	// page 1 has no PieceInfo, private data is found on the catalog instead
+	data := testPlainPDF(true)
+	conf := testConfiguration()
+	conf.AllowMissingPrivateData = true
+	f, err := ParseContext(context.Background(), bytes.NewReader(data), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.PrivateDataAbsent != nil {
+		t.Errorf("private data absent because of %v", f.PrivateDataAbsent)
+	}
+	if f.PrivateData == nil {
+		t.Fatal("no private data")
+	}
+	want := []string{"%!PS-Adobe-3.0", "%AI5_BeginLayer", "%AI5_EndLayer--"}
+	if lines := testReadLines(t, f.PrivateData); !reflect.DeepEqual(lines, want) {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+	if len(f.PrivateStreams) != 1 || !reflect.DeepEqual(f.PrivateStreams[0].Pages, []int{0}) {
+		t.Errorf("got private streams %+v, want one of the catalog", f.PrivateStreams)
+	}
+}
+
+func TestAllowMissingPrivateDataKeepsOtherErrors(t *testing.T) {
+	// NumBlock promises a block which is not there
+	objects := testFullObjects()
+	objects[4] = strings.Replace(objects[4], "/NumBlock 1", "/NumBlock 2", 1)
+	data := testPDF(objects...)
+	conf := testConfiguration()
+	conf.AllowMissingPrivateData = true
+	if _, err := ParseContext(context.Background(), bytes.NewReader(data), conf); !errors.Is(err, ErrTruncatedPrivateData) {
+		t.Errorf("got %v, want %v", err, ErrTruncatedPrivateData)
+	}
+}
@@ -0,0 +1,224 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
+	"github.com/pkg/errors"
+)
+
+// PlacedItem is a raster or document placed into the artwork, either linked to its original file or embedded
+type PlacedItem struct {
+	// Path of the original file, empty for rasters which never had one, e.g. pasted
+	Path string `json:"path,omitempty"`
+	// Format is lowercase extension of Path, e.g. png, psd or pdf
+	Format   string     `json:"format,omitempty"`
+	Embedded bool       `json:"embedded"`
+	Status   LinkStatus `json:"status"`
+	// Transform is the [a b c d tx ty] matrix of the placement as written in private data
+	Transform []float64 `json:"transform,omitempty"`
+	// Width and Height are raster dimensions in pixels, 0 for documents
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// XObject is object number of the image XObject drawing the raster, 0 if it was not matched - see IllustratorFile.PlacedItems
+	XObject int `json:"xObject,omitempty"`
+}
+
+// LinkStatus tells where content of a placed item comes from
+type LinkStatus string
+
+const (
+	// LinkStatusLinked is content kept in the original file only, Path refers to it
+	LinkStatusLinked LinkStatus = "linked"
+	// LinkStatusEmbedded is content copied from the original file at Path
+	LinkStatusEmbedded LinkStatus = "embedded"
+	// LinkStatusPasted is embedded content which never had an original file
+	LinkStatusPasted LinkStatus = "pasted"
+)
+
+var (
+	beginRasterRe = regexp.MustCompile(`^%AI\d+_BeginRaster`)
+	endRasterRe   = regexp.MustCompile(`^%AI\d+_EndRaster`)
+	// DSC comments of placed EPS and PDF files - BeginDocument precedes the document itself, IncludeDocument refers to it
+	documentRe = regexp.MustCompile(`^%%(Begin|Include)Document:\s*(.*)`)
+)
+
+// ReadPlacedItems returns placed items of private data in order of artwork. Rasters are %AI5_BeginRaster ...
+// %AI5_EndRaster blocks - `(path) embedded XG` gives the original file, if any, and `[a b c d tx ty] llx lly urx ury
+// width height ...` operands of XI the placement. Documents are %%BeginDocument (embedded) and %%IncludeDocument
+// (linked) comments. It consumes pd.
+func ReadPlacedItems(ctx context.Context, pd PrivateData) ([]*PlacedItem, error) {
+	var items []*PlacedItem
+	var raster *PlacedItem
+	continuation := false
+	for pd.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		line := pd.Bytes()
+		lineStart := !continuation
+		continuation = pd.Continued()
+		if !lineStart || len(line) < 2 {
+			continue
+		}
+		switch {
+		case beginRasterRe.Match(line):
+			raster = &PlacedItem{Embedded: true}
+		case endRasterRe.Match(line):
+			if raster != nil {
+				raster.Status = raster.linkStatus()
+				items = append(items, raster)
+				raster = nil
+			}
+		case raster != nil && (bytes.HasSuffix(line, []byte(" XG")) || line[0] == '['):
+			if line[0] == '[' && !bytes.HasSuffix(line, []byte(" XI")) {
+				// XI follows its operands after %%BeginData comment
+				line = append(append([]byte{}, line...), " XI"...)
+			}
+			if err := raster.parseRecord(line); err != nil {
+				return nil, errors.WithMessagef(err, "raster %q", line)
+			}
+		default:
+			if match := documentRe.FindSubmatch(line); match != nil {
+				item := PlacedItem{Path: documentPath(match[2]), Embedded: string(match[1]) == "Begin"}
+				item.Format = fileFormat(item.Path)
+				item.Status = item.linkStatus()
+				items = append(items, &item)
+			}
+		}
+	}
+	if err := pd.Err(); err != nil {
+		return nil, errors.WithMessage(err, "while reading placed items")
+	}
+	return items, nil
+}
+
+// parseRecord handles raster path (XG) and placement (XI)
+func (item *PlacedItem) parseRecord(line []byte) error {
+	parser := contents.NewParser(line)
+	if !parser.Scan() {
+		return parser.Err()
+	}
+	op := parser.Operator()
+	switch op.Name {
+	case "XG":
+		if len(op.Args) < 2 {
+			return errors.Errorf("XG has %d operands, 2 expected", len(op.Args))
+		}
+		if str, ok := op.Args[0].(contents.LiteralString); ok {
+			item.Path = layerName(str.Unescape())
+			item.Format = fileFormat(item.Path)
+		}
+		embedded, ok := op.Args[1].(contents.Number)
+		if !ok {
+			return errors.Errorf("XG operand 1 is %s, not a number", op.Args[1].OperandType())
+		}
+		item.Embedded = item.Path == "" || embedded != 0
+	case "XI":
+		if len(op.Args) < 7 {
+			return errors.Errorf("XI has %d operands, at least 7 expected", len(op.Args))
+		}
+		arr, ok := op.Args[0].(contents.Array)
+		if !ok {
+			return errors.Errorf("XI operand 0 is %s, not an array", op.Args[0].OperandType())
+		}
+		transform, err := numbers(arr)
+		if err != nil {
+			return errors.WithMessage(err, "XI matrix")
+		}
+		size, err := numbers(op.Args[5:7])
+		if err != nil {
+			return errors.WithMessage(err, "XI")
+		}
+		item.Transform = transform
+		item.Width, item.Height = int(size[0]), int(size[1])
+	}
+	return nil
+}
+
+// linkStatus derives LinkStatus from Path and Embedded
+func (item *PlacedItem) linkStatus() LinkStatus {
+	switch {
+	case !item.Embedded:
+		return LinkStatusLinked
+	case item.Path == "":
+		return LinkStatusPasted
+	default:
+		return LinkStatusEmbedded
+	}
+}
+
+// documentPath returns path of DSC document comment, which is either a string or the rest of the line
+func documentPath(value []byte) string {
+	value = bytes.TrimSpace(value)
+	if len(value) > 0 && value[0] == '(' {
+		parser := contents.NewParser(append(append([]byte{}, value...), " _"...))
+		if parser.Scan() {
+			if name := firstString(parser.Operator().Args); name != "" {
+				return name
+			}
+		}
+	}
+	return layerName(value)
+}
+
+// fileFormat returns lowercase extension, paths may come from both Windows and classic Mac OS
+func fileFormat(filePath string) string {
+	if idx := strings.LastIndexAny(filePath, `\:`); idx >= 0 {
+		filePath = filePath[idx+1:]
+	}
+	return strings.ToLower(strings.TrimPrefix(path.Ext(filePath), "."))
+}
+
+// PlacedItems reads placed items from private data and links rasters to image XObjects painted on artboards, those
+// painted by Form XObjects included. Rasters are matched to images of the same dimensions by position, see
+// matchPlacements. Private data is read from IllustratorFile.IndexedPrivateData.
+func (f *IllustratorFile) PlacedItems(ctx context.Context) ([]*PlacedItem, error) {
+	pd, err := f.privateData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, err := ReadPlacedItems(ctx, pd)
+	pd.Close()
+	if err != nil {
+		return nil, err
+	}
+	if f.SerializedFile == nil {
+		return items, nil
+	}
+	uses, err := f.xObjectUses(ctx, "Image")
+	if err != nil {
+		return nil, errors.WithMessage(err, "while linking placed items")
+	}
+	placements := make([][]float64, len(items))
+	for idx, item := range items {
+		if item.Width > 0 && item.Height > 0 && len(item.Transform) == 6 {
+			placements[idx] = item.imageMatrix()
+		}
+	}
+	matrices := make([][]float64, len(uses))
+	for idx, use := range uses {
+		matrices[idx] = use.ctm
+	}
+	sameSize := func(placement, use int) bool {
+		width, height := uses[use].dict.IntEntry("Width"), uses[use].dict.IntEntry("Height")
+		return width != nil && height != nil && *width == items[placement].Width && *height == items[placement].Height
+	}
+	for idx, match := range matchPlacements(placements, uses, matrices, sameSize) {
+		if match >= 0 {
+			items[idx].XObject = uses[match].objNr
+		}
+	}
+	return items, nil
+}
+
+// imageMatrix returns CTM of the image XObject drawing the raster, which maps the unit square rather than pixels - rows
+// of pixels go down in private data, same as its y axis, see flipY
+func (item *PlacedItem) imageMatrix() []float64 {
+	m, w, h := item.Transform, float64(item.Width), float64(item.Height)
+	return []float64{w * m[0], -w * m[1], -h * m[2], h * m[3], m[4] + h*m[2], -m[5] - h*m[3]}
+}
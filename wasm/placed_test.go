@@ -0,0 +1,173 @@
+package wasm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPlacedItemRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected PlacedItem
+		fails    bool
+	}{
+		{
+			name:     "linked",
+			lines:    []string{`(C:\\art\\photo.PSD) 0 XG`},
+			expected: PlacedItem{Path: `C:\art\photo.PSD`, Format: "psd"},
+		},
+		{
+			name:     "embedded",
+			lines:    []string{"(Macintosh HD:art:logo.png) 1 XG"},
+			expected: PlacedItem{Path: "Macintosh HD:art:logo.png", Format: "png", Embedded: true},
+		},
+		{
+			name:     "pasted",
+			lines:    []string{"() 0 XG"},
+			expected: PlacedItem{Embedded: true},
+		},
+		{
+			name:     "placement",
+			lines:    []string{"[0.24 0 0 0.24 10 20] 0 0 640 480 640 480 8 3 0 0 0 0 XI"},
+			expected: PlacedItem{Transform: []float64{0.24, 0, 0, 0.24, 10, 20}, Width: 640, Height: 480},
+		},
+		{
+			name:  "XG without flag",
+			lines: []string{"(photo.psd) XG"},
+			fails: true,
+		},
+		{
+			name:  "XI without matrix",
+			lines: []string{"0 0 640 480 640 480 8 XI"},
+			fails: true,
+		},
+		{
+			name:  "XI without size",
+			lines: []string{"[1 0 0 1 0 0] 0 0 640 480 XI"},
+			fails: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var item PlacedItem
+			var err error
+			for _, line := range tt.lines {
+				if err = item.parseRecord([]byte(line)); err != nil {
+					break
+				}
+			}
+			if tt.fails {
+				if err == nil {
+					t.Errorf("expected error, got %+v", item)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(item, tt.expected) {
+				t.Errorf("got %+v, expected %+v", item, tt.expected)
+			}
+		})
+	}
+}
+
+var testPlacedItems = []string{
+	"%AI5_BeginRaster",
+	"(photo.psd) 0 XG",
+	"[1 0 0 1 10 20] 0 0 2 1 2 1 8 3 0 0 0 0",
+	"%%BeginData: 6",
+	"XI",
+	"%AI5_EndRaster",
+	"%%IncludeDocument: (C:\\\\art\\\\linked.eps)",
+	"%%BeginDocument: embedded.pdf",
+	"%%EndDocument",
+}
+
+func TestReadPlacedItems(t *testing.T) {
+	items, err := ReadPlacedItems(context.Background(), testPrivateData(testPlacedItems...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*PlacedItem{
+		{Path: "photo.psd", Format: "psd", Status: LinkStatusLinked, Transform: []float64{1, 0, 0, 1, 10, 20}, Width: 2, Height: 1},
+		{Path: `C:\art\linked.eps`, Format: "eps", Status: LinkStatusLinked},
+		{Path: "embedded.pdf", Format: "pdf", Embedded: true, Status: LinkStatusEmbedded},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("got %+v, expected %+v", items, expected)
+	}
+}
+
+func TestPlacedItemsKeepPrivateData(t *testing.T) {
+	image := testStream("/Type /XObject /Subtype /Image /Width 2 /Height 1 /ColorSpace /DeviceRGB /BitsPerComponent 8", "abcdef")
+	f := testIllustratorFile(t, append(testPlacedItems, testArtboardSetup...), "0 0 100 100", "/XObject << /X0 9 0 R >>",
+		"q 2 0 0 1 10 20 cm /X0 Do Q", image)
+	ctx := context.Background()
+	for run := 0; run < 2; run++ {
+		items, err := f.PlacedItems(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 3 || items[0].XObject != 9 {
+			t.Fatalf("run %d got %+v", run, items)
+		}
+		artboards, err := f.Artboards(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if artboards[0].Name != "Main" {
+			t.Errorf("run %d got artboard %q", run, artboards[0].Name)
+		}
+	}
+}
+
+func TestPlacedItemsLinking(t *testing.T) {
+	// private data has y axis pointing down, the artboard origin is at 0 100 in PDF
+	privateData := append([]string{
+		"%AI5_BeginRaster",
+		"() 0 XG",
+		"[1 0 0 1 60 40] 0 0 2 1 2 1 8 3 0 0 0 0 XI",
+		"%AI5_EndRaster",
+		"%AI5_BeginRaster",
+		"(photo.psd) 1 XG",
+		"[1 0 0 1 10 20] 0 0 2 1 2 1 8 3 0 0 0 0 XI",
+		"%AI5_EndRaster",
+		"%AI5_BeginRaster",
+		"(photo.psd) 0 XG",
+		"[0 1 -1 0 30 30] 0 0 2 1 2 1 8 3 0 0 0 0 XI",
+		"%AI5_EndRaster",
+	}, testArtboardSetup...)
+	image := func(data string) string {
+		return testStream("/Type /XObject /Subtype /Image /Width 2 /Height 1 /ColorSpace /DeviceRGB /BitsPerComponent 8", data)
+	}
+	// the rotated raster is painted by a group, which is a Form XObject
+	group := testStream("/Type /XObject /Subtype /Form /BBox [0 0 100 100] /Resources << /XObject << /Im 11 0 R >> >>",
+		"q 0 -2 1 0 29 70 cm /Im Do Q")
+	f := testIllustratorFile(t, privateData, "0 0 100 100", "/XObject << /X0 9 0 R /X1 10 0 R /G 12 0 R >>",
+		"q 2 0 0 1 10 79 cm /X0 Do Q /G Do q 2 0 0 1 60 59 cm /X1 Do Q", image("abcdef"), image("ghijkl"), image("mnopqr"), group)
+
+	items, err := f.PlacedItems(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		xObject int
+		status  LinkStatus
+	}{
+		{10, LinkStatusPasted},
+		{9, LinkStatusEmbedded},
+		{11, LinkStatusLinked},
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("got %d items, expected %d", len(items), len(expected))
+	}
+	for idx, item := range items {
+		if item.XObject != expected[idx].xObject || item.Status != expected[idx].status {
+			t.Errorf("item %d got XObject %d status %q, expected XObject %d status %q",
+				idx, item.XObject, item.Status, expected[idx].xObject, expected[idx].status)
+		}
+	}
+}
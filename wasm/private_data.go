@@ -1,13 +1,12 @@
 package wasm
 
 import (
-	"bufio"
 	"bytes"
-	"compress/zlib"
+	"context"
 	"io"
+	"regexp"
 	"strconv"
 
-	"github.com/klauspost/compress/zstd"
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
 	"github.com/pkg/errors"
 )
@@ -18,194 +17,310 @@ type PrivateData interface {
 	Scan() bool
 	// Bytes returns the most recent token generated by a call to Scan. The underlying array may point to data that will be overwritten by a subsequent call to Scan. It does no allocation.
 	Bytes() []byte
+	// Continued tells whether the line of the most recent token goes on in the next one - lines longer than BufferSize
+	// (e.g. embedded raster data) are returned in parts instead of failing.
+	Continued() bool
 	// Err returns the first non-EOF error that was encountered by the Scanner.
 	Err() error
 }
 
 var (
+	// BufferSize bounds length of a token returned by PrivateData, longer lines are split, see PrivateData.Continued
 	BufferSize = 128 * 1024
 )
 
 type closer struct {
-	*bufio.Scanner
-	zstd *zstd.Decoder
-	zlib io.Closer
+	*lineReader
+	decompressors []io.Closer
 }
 
 func (cl *closer) Close() error {
-	if cl.zstd != nil {
-		cl.zstd.Close()
-	}
-	if cl.zlib != nil {
-		cl.zlib.Close()
+	for _, d := range cl.decompressors {
+		d.Close()
 	}
 	return nil
 }
 
-// this is _almost_ copy of https://pkg.go.dev/bufio#ScanLines
-// but instead looks for \r - in line w/ how private data behaves
-func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	if atEOF && len(data) == 0 {
-		return 0, nil, nil
-	}
-	if i := bytes.IndexByte(data, '\r'); i >= 0 {
-		// We have a full newline-terminated line.
-		return i + 1, data[0:i], nil
-	}
-	// If we're at EOF, we have a final, non-terminated line. Return it.
-	if atEOF {
-		return len(data), data, nil
-	}
-	// Request more data.
-	return 0, nil, nil
-}
-
-func (dc *decompressor) process() (PrivateData, error) {
+// process concatenates segments in order, decompression stops with ctx.Err() once ctx is done or with LimitError once lim is exceeded.
+// objNr is the Private dict, lim charges its decompressed data once however many times it's processed.
+func (dc *decompressor) process(ctx context.Context, lim *limiter, objNr int) (PrivateData, error) {
 	var closer closer
-	reader := io.MultiReader(dc.chunks...)
-	if len(dc.zstdChunks) > 0 {
-		chunk, err := zstd.NewReader(io.MultiReader(dc.zstdChunks...))
-		if err != nil {
-			return nil, errors.Wrapf(err, "while preparing zstd decoder")
+	readers := make([]io.Reader, 0, len(dc.segments))
+	for _, seg := range dc.segments {
+		reader := multiReader(seg.chunks)
+		if seg.decompress != nil {
+			rc, err := seg.decompress(&contextReader{ctx, reader})
+			if err != nil {
+				closer.Close()
+				return nil, errors.Wrapf(err, "while preparing %s decoder", seg.header)
+			}
+			closer.decompressors = append(closer.decompressors, rc)
+			reader = rc
 		}
-		closer.zstd = chunk
-		reader = io.MultiReader(reader, chunk)
+		readers = append(readers, reader)
 	}
-	if len(dc.zlibChunks) > 0 {
-		chunk, err := zlib.NewReader(io.MultiReader(dc.zlibChunks...))
-		if err != nil {
-			return nil, errors.Wrapf(err, "while preparing zlib decoder")
-		}
-		closer.zlib = chunk
-		reader = io.MultiReader(reader, chunk)
-	}
-	scanner := bufio.NewScanner(reader)
-	buffer := make([]byte, BufferSize)
-	scanner.Buffer(buffer, len(buffer))
-	scanner.Split(scanLines)
-	closer.Scanner = scanner
+	closer.lineReader = newLineReader(&contextReader{ctx, &limitReader{l: lim, r: io.MultiReader(readers...), objNr: objNr}}, false)
 	return &closer, nil
 }
 
+// segment is a run of chunks compressed as a single stream, or uncompressed if decompress is nil
+type segment struct {
+	header     string
+	decompress Decompressor
+	chunks     [][]byte
+}
+
+// decompressor keeps segments in order of blocks, chunks are kept as bytes so that each process starts from the beginning
 type decompressor struct {
-	chunks     []io.Reader
-	zstdChunks []io.Reader
-	zlibChunks []io.Reader
+	segments []segment
 }
 
-func (dc *decompressor) addZstd(chunk *zstdChunk) error {
-	dc.zstdChunks = append(dc.zstdChunks, bytes.NewBuffer(chunk.compressed[len(chunk.header):]))
-	return nil
+func multiReader(chunks [][]byte) io.Reader {
+	readers := make([]io.Reader, len(chunks))
+	for idx, chunk := range chunks {
+		readers[idx] = bytes.NewReader(chunk)
+	}
+	return io.MultiReader(readers...)
 }
 
-func (dc *decompressor) addZlib(chunk *zlibChunk) error {
-	dc.zlibChunks = append(dc.zlibChunks, bytes.NewBuffer(chunk.compressed[len(chunk.header):]))
-	return nil
+// compressed tells whether the last segment is compressed, which then continues in following blocks
+func (dc *decompressor) compressed() bool {
+	return len(dc.segments) > 0 && dc.segments[len(dc.segments)-1].decompress != nil
 }
 
-func (dc *decompressor) addRaw(bs []byte) error {
-	dc.chunks = append(dc.chunks, bytes.NewBuffer(bs))
-	return nil
+// start opens a new compressed segment, chunk starts with header
+func (dc *decompressor) start(header string, d Decompressor, chunk []byte) {
+	dc.segments = append(dc.segments, segment{header, d, [][]byte{chunk[len(header):]}})
 }
 
-type zstdChunk struct {
-	compressed []byte
-	header     string
+// add appends chunk to the last segment, or to a new uncompressed one if there is none
+func (dc *decompressor) add(chunk []byte) {
+	if len(dc.segments) == 0 {
+		dc.segments = append(dc.segments, segment{})
+	}
+	last := &dc.segments[len(dc.segments)-1]
+	last.chunks = append(last.chunks, chunk)
 }
 
-type zlibChunk struct {
-	compressed []byte
-	header     string
+// addUncompressed appends chunk to the last segment, or to a new one if the last is compressed
+func (dc *decompressor) addUncompressed(chunk []byte) {
+	if dc.compressed() {
+		dc.segments = append(dc.segments, segment{})
+	}
+	dc.add(chunk)
 }
 
+// compressionHeaderRe matches headers of unregistered compression methods, which are named either like
+// %AI24_ZStandard_Data or like %AI12_CompressedData - markers such as %AI9_PrivateDataBegin are not headers
+var compressionHeaderRe = regexp.MustCompile(`^%AI\d+_(?:[A-Za-z0-9]+?_Data|[A-Za-z0-9]*?CompressedData)`)
+
 type parsingCtx struct {
-	pdf                *pdfcpu.Context
-	private            pdfcpu.Dict
-	currentCompression int
-	dc                 decompressor
+	pdf     *pdfcpu.Context
+	limiter *limiter
+	private pdfcpu.Dict
+	dc      decompressor
 }
 
+// emitChunk adds block key to the segments. Every block is checked for a compression header, which starts a new
+// compressed segment. Blocks following a compressed one continue it if they are stored unfiltered, same as Illustrator
+// writes them - a filtered block is uncompressed data, which starts a new segment.
 func (ctx *parsingCtx) emitChunk(key string) error {
 	meta := ctx.private[key]
 	chunk, _, err := ctx.pdf.DereferenceStreamDict(meta)
 	if err != nil {
 		return err
 	}
-	if ctx.currentCompression == compressionZstd {
-		return ctx.dc.addZstd(&zstdChunk{chunk.Raw, ""})
+	if chunk == nil {
+		return errors.Errorf("%s is not a stream", key)
 	}
-	if ctx.currentCompression == compressionZlib {
-		return ctx.dc.addZlib(&zlibChunk{chunk.Raw, ""})
+	if max := ctx.limiter.maxPrivateDataBytes(); max > 0 {
+		if size := decodedSize(chunk, max); size > max {
+			return &LimitError{Limit: LimitPrivateDataBytes, Max: max, Value: size}
+		}
 	}
 	if err = chunk.Decode(); err != nil {
 		return errors.Wrap(err, "when decoding chunk")
 	}
-	if string(chunk.Content[0]) == "%" && string(chunk.Content[:20]) == "%AI24_ZStandard_Data" {
-		ctx.currentCompression = compressionZstd
-		return ctx.dc.addZstd(&zstdChunk{chunk.Content, string(chunk.Content[:20])})
+	if header, d := lookupDecompressor(chunk.Content); d != nil {
+		ctx.dc.start(header, d, chunk.Content)
+		return nil
+	}
+	if header := compressionHeaderRe.Find(chunk.Content); header != nil {
+		return newError(ErrUnsupportedCompression, errors.Errorf("header %q, see RegisterDecompressor", header))
 	}
-	if string(chunk.Content[0]) == "%" && string(chunk.Content[:20]) == "%AI12_CompressedData" {
-		ctx.currentCompression = compressionZlib
-		return ctx.dc.addZlib(&zlibChunk{chunk.Content, string(chunk.Content[:20])})
+	if ctx.dc.compressed() && len(chunk.FilterPipeline) == 0 {
+		ctx.dc.add(chunk.Content)
+		return nil
 	}
-	return ctx.dc.addRaw(chunk.Content)
+	ctx.dc.addUncompressed(chunk.Content)
+	return nil
 }
 
-const (
-	compressionNone = iota
-	compressionZlib
-	compressionZstd
-)
+// PrivateStream is a PieceInfo->Illustrator->Private dict, usually shared by all pages - files assembled from several
+// documents may carry more of them
+type PrivateStream struct {
+	// ObjNr of the Private dict
+	ObjNr int
+	// Pages referring to the dict in document order, 0 stands for the catalog
+	Pages []int
+
+	dict pdfcpu.Dict
+	dc   *decompressor
+	lim  *limiter
+}
 
-func extractPrivateData(ctx *pdfcpu.Context) (PrivateData, error) {
-	dict, _, _, err := ctx.PageDict(1, false)
+// Open returns a new scanner over the stream, it must be closed same as IllustratorFile.PrivateData
+func (ps *PrivateStream) Open(ctx context.Context) (PrivateData, error) {
+	return ps.dc.process(ctx, ps.lim, ps.ObjNr)
+}
+
+// extractPrivateData opens the first of private streams, which are found on pages in document order and then on the catalog
+func extractPrivateData(ctx context.Context, pdfCtx *pdfcpu.Context, lim *limiter, diagnostics *[]Diagnostic) (PrivateData, []PrivateStream, error) {
+	streams, err := extractPrivateStreams(ctx, pdfCtx, lim, diagnostics)
 	if err != nil {
-		return nil, errors.WithMessage(err, "pageDict(1)")
+		return nil, nil, err
 	}
-	ref, ok := dict.DictEntry("PieceInfo")["Illustrator"].(pdfcpu.IndirectRef)
-	if !ok {
-		return nil, errors.New("PieceInfo->Illustrator is not IndirectRef")
+	pd, err := streams[0].Open(ctx)
+	return pd, streams, err
+}
+
+// extractPrivateStreams reads private dicts of pages and the catalog. Malformed PieceInfo of one of them is appended
+// to diagnostics (if not nil) when there are private dicts elsewhere, otherwise it is returned.
+func extractPrivateStreams(ctx context.Context, pdfCtx *pdfcpu.Context, lim *limiter, diagnostics *[]Diagnostic) ([]PrivateStream, error) {
+	if err := pdfCtx.EnsurePageCount(); err != nil {
+		return nil, errors.WithMessage(err, "while counting pages")
 	}
-	obj1, err := ctx.DereferenceDict(ref)
-	if err != nil {
-		return nil, errors.WithMessage(err, "Dereference(PieceInfo->Illustrator) is not Dict")
+	var owners []pdfcpu.Dict
+	var ownerObjNrs []int
+	for pageNr := 1; pageNr <= pdfCtx.PageCount; pageNr++ {
+		dict, ref, _, err := pdfCtx.PageDict(pageNr, false)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "pageDict(%d)", pageNr)
+		}
+		owners = append(owners, dict)
+		ownerObjNrs = append(ownerObjNrs, refObjNr(ref))
 	}
-	obj2, err := ctx.Dereference(obj1.IndirectRefEntry("Private"))
+	catalog, err := pdfCtx.Catalog()
 	if err != nil {
-		return nil, errors.WithMessage(err, "PieceInfo->Illustrator->Private is not a valid object")
+		return nil, errors.WithMessage(err, "catalog")
+	}
+	owners = append(owners, catalog)
+	ownerObjNrs = append(ownerObjNrs, refObjNr(pdfCtx.Root))
+
+	var streams []PrivateStream
+	byObjNr := map[int]int{}
+	var firstErr error
+	var malformed []Diagnostic
+	for idx, owner := range owners {
+		pageNr := idx + 1
+		if idx == len(owners)-1 {
+			pageNr = 0
+		}
+		objNr, private, err := privateDict(pdfCtx, owner)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = errors.WithMessagef(err, "page %d", pageNr)
+			}
+			// pages without PieceInfo are common, the catalog usually has none
+			if _, ok := owner.Find("PieceInfo"); ok {
+				malformed = append(malformed, Diagnostic{ObjNr: ownerObjNrs[idx], Key: "PieceInfo", Message: err.Error()})
+			}
+			continue
+		}
+		if i, ok := byObjNr[objNr]; ok {
+			streams[i].Pages = append(streams[i].Pages, pageNr)
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		dc, err := readPrivateStream(ctx, pdfCtx, private, lim)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "private dict (obj#:%d)", objNr)
+		}
+		byObjNr[objNr] = len(streams)
+		streams = append(streams, PrivateStream{ObjNr: objNr, Pages: []int{pageNr}, dict: private, dc: dc, lim: lim})
+	}
+	if len(streams) == 0 {
+		if firstErr == nil {
+			firstErr = newError(ErrMissingPieceInfo, errors.New("no pages"))
+		}
+		return nil, firstErr
+	}
+	if diagnostics != nil {
+		*diagnostics = append(*diagnostics, malformed...)
+	}
+	return streams, nil
+}
+
+// refObjNr returns object number of ref, 0 if nil
+func refObjNr(ref *pdfcpu.IndirectRef) int {
+	if ref == nil {
+		return 0
+	}
+	return ref.ObjectNumber.Value()
+}
+
+// privateDict resolves PieceInfo->Illustrator->Private of a page or the catalog
+func privateDict(pdfCtx *pdfcpu.Context, owner pdfcpu.Dict) (int, pdfcpu.Dict, error) {
+	pieceInfo, err := pdfCtx.DereferenceDict(owner["PieceInfo"])
+	if err != nil || pieceInfo == nil {
+		return 0, nil, newError(ErrMissingPieceInfo, errors.New("PieceInfo is not Dict"))
 	}
-	ref2, ok := obj2.(*pdfcpu.IndirectRef)
+	illustrator, err := pdfCtx.DereferenceDict(pieceInfo["Illustrator"])
+	if err != nil || illustrator == nil {
+		return 0, nil, newError(ErrMissingPieceInfo, errors.New("Dereference(PieceInfo->Illustrator) is not Dict"))
+	}
+	ref, ok := illustrator["Private"].(pdfcpu.IndirectRef)
 	if !ok {
-		return nil, errors.New("PieceInfo->Illustrator->Private is not IndirectRef")
+		return 0, nil, newError(ErrMissingPieceInfo, errors.New("PieceInfo->Illustrator->Private is not IndirectRef"))
 	}
-	private, err := ctx.DereferenceDict(*ref2) // bug?
-	if err != nil {
-		return nil, errors.WithMessage(err, "PieceInfo->Illustrator->Private is not Dict")
+	private, err := pdfCtx.DereferenceDict(ref)
+	if err != nil || private == nil {
+		return 0, nil, newError(ErrMissingPieceInfo, errors.New("PieceInfo->Illustrator->Private is not Dict"))
 	}
+	return ref.ObjectNumber.Value(), private, nil
+}
 
+func readPrivateStream(ctx context.Context, pdfCtx *pdfcpu.Context, private pdfcpu.Dict, lim *limiter) (*decompressor, error) {
 	pCtx := parsingCtx{
-		pdf:     ctx,
+		pdf:     pdfCtx,
+		limiter: lim,
 		private: private,
 		dc:      decompressor{},
 	}
 
-	err = pCtx.emitChunk("AIMetaData")
+	err := pCtx.emitChunk("AIMetaData")
 	if err != nil {
 		return nil, errors.WithMessage(err, "whilst reading AIMetaData")
 	}
 
-	numBlock := private["NumBlock"].(pdfcpu.Integer).Value()
+	numBlockObj, ok := private["NumBlock"].(pdfcpu.Integer)
+	if !ok {
+		return nil, newError(ErrTruncatedPrivateData, errors.New("NumBlock is not Integer"))
+	}
+	numBlock := numBlockObj.Value()
 	for i := 1; i <= numBlock; i += 1 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		key := "AIPrivateData" + strconv.Itoa(i)
 		err := pCtx.emitChunk(key)
+		var limitErr *LimitError
+		if errors.As(err, &limitErr) || errors.Is(err, ErrUnsupportedCompression) {
+			return nil, errors.WithMessage(err, "whilst reading "+key)
+		}
 		if err != nil {
 			altKey := "AIPDFPrivateData" + strconv.Itoa(i)
 			err := pCtx.emitChunk(altKey)
+			if errors.Is(err, ErrUnsupportedCompression) {
+				return nil, errors.WithMessage(err, "whilst reading "+altKey)
+			}
 			if err != nil {
-				return nil, errors.WithMessage(err, "whilst reading "+key)
+				return nil, errors.WithMessage(newError(ErrTruncatedPrivateData, err), "whilst reading "+key)
 			}
 		}
 	}
 
-	return pCtx.dc.process()
+	return &pCtx.dc, nil
 }
@@ -0,0 +1,154 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// testPrivateData returns PrivateData of lines, which are joined by \r same as in files
+func testPrivateData(lines ...string) PrivateData {
+	return &closer{lineReader: newLineReader(bytes.NewReader([]byte(strings.Join(lines, "\r"))), false)}
+}
+
+// testStream returns body of a stream object, dict holds entries other than Length
+func testStream(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+// testIllustratorPDF returns a single page PDF with private data lines stored uncompressed in one block, objects are
+// numbered from 9 on
+func testIllustratorPDF(privateData []string, mediaBox, resources, content string, objects ...string) []byte {
+	return testPDF(testIllustratorObjects(privateData, mediaBox, resources, content, objects...)...)
+}
+
+// testIllustratorObjects returns objects of testIllustratorPDF
+func testIllustratorObjects(privateData []string, mediaBox, resources, content string, objects ...string) []string {
+	return append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [%s] >>", mediaBox),
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Contents 8 0 R /Resources << %s >> /PieceInfo << /Illustrator 4 0 R >> >>", resources),
+		"<< /Private 5 0 R >>",
+		"<< /AIMetaData 6 0 R /NumBlock 1 /AIPrivateData1 7 0 R >>",
+		testStream("", "%!PS-Adobe-3.0\r"),
+		testStream("", strings.Join(privateData, "\r")),
+		testStream("", content),
+	}, objects...)
+}
+
+// testPDF returns PDF of objects numbered from 1 on, the first one being the catalog
+func testPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+	offsets := make([]int, len(objects))
+	for idx, object := range objects {
+		offsets[idx] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", idx+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// testConfiguration requests private data and tolerates what testIllustratorPDF leaves out
+func testConfiguration() *Configuration {
+	conf := NewConfiguration()
+	conf.WithPrivateData = true
+	conf.ValidationPolicy = ValidationRetryRelaxed
+	return conf
+}
+
+// testIllustratorFile parses testIllustratorPDF
+func testIllustratorFile(t *testing.T, privateData []string, mediaBox, resources, content string, objects ...string) *IllustratorFile {
+	t.Helper()
+	data := testIllustratorPDF(privateData, mediaBox, resources, content, objects...)
+	f, err := ParseContext(context.Background(), bytes.NewReader(data), testConfiguration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.PrivateData.Close() })
+	return f
+}
+
+func TestPrivateStreams(t *testing.T) {
+	data := testPDF(
+		"<< /Type /Catalog /Pages 2 0 R /PieceInfo << /Illustrator 11 0 R >> >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R 6 0 R] /Count 4 /MediaBox [0 0 100 100] >>",
+		"<< /Type /Page /Parent 2 0 R /PieceInfo << /Illustrator 7 0 R >> >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /PieceInfo << /Illustrator 7 0 R >> >>",
+		// Private must be an indirect reference
+		"<< /Type /Page /Parent 2 0 R /PieceInfo << /Illustrator << /Private 8 >> >> >>",
+		"<< /Private 8 0 R >>",
+		"<< /AIMetaData 9 0 R /NumBlock 1 /AIPrivateData1 10 0 R >>",
+		testStream("", "%!PS-Adobe-3.0\r"),
+		testStream("", "pages"),
+		"<< /Private 12 0 R >>",
+		"<< /AIMetaData 9 0 R /NumBlock 1 /AIPrivateData1 13 0 R >>",
+		testStream("", "catalog"),
+	)
+	ctx := context.Background()
+	f, err := ParseContext(ctx, bytes.NewReader(data), testConfiguration())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type stream struct {
+		objNr int
+		pages []int
+		lines []string
+	}
+	var got []stream
+	for idx := range f.PrivateStreams {
+		ps := &f.PrivateStreams[idx]
+		pd, err := ps.Open(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, stream{ps.ObjNr, ps.Pages, testReadLines(t, pd)})
+	}
+	want := []stream{
+		{8, []int{1, 3}, []string{"%!PS-Adobe-3.0", "pages"}},
+		{12, []int{0}, []string{"%!PS-Adobe-3.0", "catalog"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if lines := testReadLines(t, f.PrivateData); !reflect.DeepEqual(lines, want[0].lines) {
+		t.Errorf("PrivateData got %q, want the first stream", lines)
+	}
+
+	// page 2 has no PieceInfo, which is fine
+	var malformed []Diagnostic
+	for _, d := range f.Diagnostics {
+		if d.Key == "PieceInfo" {
+			malformed = append(malformed, d)
+		}
+	}
+	if len(malformed) != 1 || malformed[0].ObjNr != 6 || !strings.Contains(malformed[0].Message, "IndirectRef") {
+		t.Errorf("got diagnostics %+v, want malformed PieceInfo of page 4", f.Diagnostics)
+	}
+}
+
+func TestPrivateStreamsMissing(t *testing.T) {
+	data := testPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 100 100] >>",
+		"<< /Type /Page /Parent 2 0 R /PieceInfo << /Illustrator << /Private 4 >> >> >>",
+		"<< /AIMetaData 5 0 R /NumBlock 0 >>",
+		testStream("", "%!PS-Adobe-3.0\r"),
+	)
+	_, err := ParseContext(context.Background(), bytes.NewReader(data), testConfiguration())
+	if !errors.Is(err, ErrMissingPieceInfo) || !strings.Contains(err.Error(), "page 1") {
+		t.Errorf("got %v, want %v of page 1", err, ErrMissingPieceInfo)
+	}
+}
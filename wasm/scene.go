@@ -0,0 +1,29 @@
+package wasm
+
+import (
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// Scene reduces content stream objNr into a tree of paths, texts, marked contexts, XObjects and shadings.
+// When resources are nil, ones from stream's own dict are used - as in Form XObjects.
+// It requires SerializedFile, so it fails for legacy files and with Configuration.SkipSerialization.
+func (f *IllustratorFile) Scene(objNr int, resources pdfcpu.Dict, strictPopplerCompat bool) ([]contents.Node, error) {
+	if f.SerializedFile == nil {
+		return nil, errors.New("scene requires serialized file")
+	}
+	sd, ok := f.StreamDicts[objNr]
+	if !ok {
+		return nil, errors.Errorf("unknown stream dict %d", objNr)
+	}
+	xRefTable := &f.SerializedFile.XRefTable
+	if resources == nil {
+		return contents.ReduceStreamDict(xRefTable, sd, strictPopplerCompat)
+	}
+	ops, err := contents.ParseStreamDict(sd)
+	if err != nil {
+		return nil, err
+	}
+	return contents.NewReducer(xRefTable, resources, strictPopplerCompat).Run(ops)
+}
@@ -0,0 +1,177 @@
+package wasm
+
+import (
+	"regexp"
+)
+
+type SectionKind string
+
+const (
+	// %AI11_BeginTextDocument ... %AI11_EndTextDocument
+	SectionTextDocument SectionKind = "TextDocument"
+	// single line of form `%_(Artboard 1) /UnicodeString (Name) ,` - Section.Name holds the name itself
+	SectionName SectionKind = "Name"
+	// %%BeginSetup ... %%EndSetup and %AI*_BeginDocumentSetup ... %AI*_EndDocumentSetup
+	SectionDocumentSetup SectionKind = "DocumentSetup"
+	// %AI5_BeginPalette ... %AI5_EndPalette and %AI*_BeginSwatches ... %AI*_EndSwatches
+	SectionSwatches SectionKind = "Swatches"
+	// any other %AI*_Begin... / %%Begin... block
+	SectionOther SectionKind = "Other"
+)
+
+// Section is a block of private data delimited by Begin/End markers
+type Section struct {
+	Kind SectionKind
+	// Version is the prefix of the marker, e.g. AI11 for %AI11_BeginTextDocument. Empty for DSC (%%Begin...) markers.
+	Version string
+	// Name is the part of the marker after Begin, e.g. TextDocument
+	Name string
+	// Start and End are byte offsets in decompressed private data, Start points at the begin marker, End is right after the end marker
+	Start int64
+	End   int64
+	// Depth is the number of sections this one is nested in
+	Depth int
+	// Lines holds lines between the markers except markers of nested sections, filled only for kinds passed to
+	// NewSectionSplitter
+	Lines [][]byte
+}
+
+var (
+	beginMarkerRe = regexp.MustCompile(`^%(?:(AI\d+)_|%)Begin(\w+)`)
+	endMarkerRe   = regexp.MustCompile(`^%(?:(AI\d+)_|%)End(\w+)`)
+)
+
+func sectionKind(name string) SectionKind {
+	switch name {
+	case "TextDocument":
+		return SectionTextDocument
+	case "Setup", "DocumentSetup":
+		return SectionDocumentSetup
+	case "Palette", "Swatches":
+		return SectionSwatches
+	}
+	return SectionOther
+}
+
+// SectionSplitter streams sections of private data - Go counterpart of src/private-data/section-splitter.ts.
+// Sections are emitted once finished, so nested sections come before the enclosing one.
+type SectionSplitter struct {
+	pd      PrivateData
+	collect map[SectionKind]bool
+
+	offset int64
+	// continuation is set while parts of a line longer than BufferSize are read
+	continuation bool
+	stack        []*Section
+	section      *Section
+	pending      []*Section
+}
+
+// NewSectionSplitter reads sections from pd, keeping content lines only for given kinds -
+// e.g. Layer sections span the whole artwork, so collecting those would buffer most of the file.
+func NewSectionSplitter(pd PrivateData, collect ...SectionKind) *SectionSplitter {
+	ss := SectionSplitter{pd: pd, collect: make(map[SectionKind]bool)}
+	for _, kind := range collect {
+		ss.collect[kind] = true
+	}
+	return &ss
+}
+
+// Scan advances the SectionSplitter to the next finished section, which will then be available through the Section method.
+func (ss *SectionSplitter) Scan() bool {
+	for len(ss.pending) == 0 {
+		if !ss.pd.Scan() {
+			// sections left open at the end are emitted as they are
+			for len(ss.stack) > 0 {
+				ss.pop(ss.offset)
+			}
+			if len(ss.pending) == 0 {
+				return false
+			}
+			break
+		}
+		ss.handleLine(ss.pd.Bytes(), ss.pd.Continued())
+	}
+	ss.section = ss.pending[0]
+	ss.pending = ss.pending[1:]
+	return true
+}
+
+// Section returns the most recent section generated by a call to Scan.
+func (ss *SectionSplitter) Section() *Section {
+	return ss.section
+}
+
+// Err returns the first non-EOF error that was encountered by the underlying PrivateData.
+func (ss *SectionSplitter) Err() error {
+	return ss.pd.Err()
+}
+
+func (ss *SectionSplitter) pop(end int64) {
+	top := ss.stack[len(ss.stack)-1]
+	ss.stack = ss.stack[:len(ss.stack)-1]
+	top.End = end
+	ss.pending = append(ss.pending, top)
+}
+
+// terminatedLines is implemented by PrivateData which tells whether a line was ended by a terminator, so that offsets do
+// not go past the end of data lacking the terminator of its last line
+type terminatedLines interface {
+	Terminated() bool
+}
+
+func (ss *SectionSplitter) handleLine(line []byte, continued bool) {
+	start := ss.offset
+	ss.offset += int64(len(line))
+	if tl, ok := ss.pd.(terminatedLines); !continued && (!ok || tl.Terminated()) {
+		ss.offset++ // lines are split on \r
+	}
+	continuation := ss.continuation
+	ss.continuation = continued
+	if !continuation && len(line) > 1 && line[0] == '%' {
+		if match := beginMarkerRe.FindSubmatch(line); match != nil {
+			ss.stack = append(ss.stack, &Section{
+				Kind:    sectionKind(string(match[2])),
+				Version: string(match[1]),
+				Name:    string(match[2]),
+				Start:   start,
+				Depth:   len(ss.stack),
+			})
+			return
+		}
+		if match := endMarkerRe.FindSubmatch(line); match != nil {
+			name := string(match[2])
+			for idx := len(ss.stack) - 1; idx >= 0; idx-- {
+				if ss.stack[idx].Name != name {
+					continue
+				}
+				// unterminated inner sections are closed together with the outer one
+				for len(ss.stack) > idx {
+					ss.pop(ss.offset)
+				}
+				return
+			}
+			// End without respective Begin - treat as a regular line
+		}
+		if match := artboardNameRe.FindSubmatch(line); match != nil {
+			ss.pending = append(ss.pending, &Section{
+				Kind:  SectionName,
+				Name:  string(match[1]),
+				Start: start,
+				End:   ss.offset,
+				Depth: len(ss.stack),
+			})
+		}
+	}
+	for _, section := range ss.stack {
+		if !ss.collect[section.Kind] {
+			continue
+		}
+		if continuation && len(section.Lines) > 0 {
+			last := len(section.Lines) - 1
+			section.Lines[last] = append(section.Lines[last], line...)
+		} else {
+			section.Lines = append(section.Lines, append([]byte(nil), line...))
+		}
+	}
+}
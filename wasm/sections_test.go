@@ -0,0 +1,121 @@
+package wasm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSectionSplitter(t *testing.T) {
+	lines := []string{
+		"%!PS-Adobe-3.0",
+		"%AI5_BeginLayer",
+		"%_(Artboard 1) /UnicodeString (Name) ,",
+		"%%BeginSetup",
+		"setup line",
+		"%AI9_EndUnknown",
+		"%%EndSetup",
+		"%AI11_BeginTextDocument",
+		"/AI11TextDocument : /ASCII85Decode ,",
+		"%AI11_EndTextDocument",
+		"%AI5_BeginPalette",
+		"%AI5_BeginSwatch",
+		"%AI5_EndPalette",
+		"%AI5_EndLayer--",
+		"%AI10_BeginSymbol",
+	}
+	data := strings.Join(lines, "\r") + "\r"
+	// offset returns start of the first line starting with marker
+	offset := func(marker string) int64 {
+		return int64(strings.Index(data, "\r"+marker) + 1)
+	}
+	end := func(marker string) int64 {
+		return offset(marker) + int64(len(marker)) + 1
+	}
+	expected := []Section{
+		{Kind: SectionName, Name: "Artboard 1", Start: offset("%_(Artboard"), End: offset("%%BeginSetup"), Depth: 1},
+		{Kind: SectionDocumentSetup, Name: "Setup", Start: offset("%%BeginSetup"), End: end("%%EndSetup"), Depth: 1,
+			Lines: [][]byte{[]byte("setup line"), []byte("%AI9_EndUnknown")}},
+		{Kind: SectionTextDocument, Version: "AI11", Name: "TextDocument", Start: offset("%AI11_Begin"), End: end("%AI11_EndTextDocument"), Depth: 1},
+		// unterminated Swatch is closed by the enclosing Palette
+		{Kind: SectionOther, Version: "AI5", Name: "Swatch", Start: offset("%AI5_BeginSwatch"), End: end("%AI5_EndPalette"), Depth: 2},
+		{Kind: SectionSwatches, Version: "AI5", Name: "Palette", Start: offset("%AI5_BeginPalette"), End: end("%AI5_EndPalette"), Depth: 1},
+		{Kind: SectionOther, Version: "AI5", Name: "Layer", Start: offset("%AI5_BeginLayer"), End: end("%AI5_EndLayer--"), Depth: 0},
+		// sections left open are emitted at the end of data, which lacks the terminator of its last line
+		{Kind: SectionOther, Version: "AI10", Name: "Symbol", Start: offset("%AI10_BeginSymbol"), End: int64(len(data) - 1), Depth: 0},
+	}
+
+	ss := NewSectionSplitter(testPrivateData(lines...), SectionDocumentSetup, SectionSwatches)
+	var got []*Section
+	for ss.Scan() {
+		got = append(got, ss.Section())
+	}
+	if err := ss.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("got %d sections, expected %d", len(got), len(expected))
+	}
+	for idx, section := range got {
+		want := expected[idx]
+		if section.Kind != want.Kind || section.Version != want.Version || section.Name != want.Name ||
+			section.Start != want.Start || section.End != want.End || section.Depth != want.Depth {
+			t.Errorf("section %d got %+v, expected %+v", idx, section, want)
+		}
+		if len(section.Lines) != len(want.Lines) {
+			t.Errorf("section %d got lines %q, expected %q", idx, section.Lines, want.Lines)
+			continue
+		}
+		for lIdx := range section.Lines {
+			if !bytes.Equal(section.Lines[lIdx], want.Lines[lIdx]) {
+				t.Errorf("section %d line %d got %q, expected %q", idx, lIdx, section.Lines[lIdx], want.Lines[lIdx])
+			}
+		}
+	}
+}
+
+func TestSectionSplitterLongLines(t *testing.T) {
+	// the second part of the line starts with a marker
+	long := strings.Repeat("x", 32) + "%AI5_BeginLayer"
+	data := "%AI11_BeginTextDocument\r/AI11TextDocument\r" + long + "\r%AI11_EndTextDocument\r"
+	lr := &lineReader{r: strings.NewReader(data), buf: make([]byte, 32)}
+	ss := NewSectionSplitter(&closer{lineReader: lr}, SectionTextDocument)
+	var sections []*Section
+	for ss.Scan() {
+		sections = append(sections, ss.Section())
+	}
+	if err := ss.Err(); err != nil {
+		t.Fatal(err)
+	}
+	// parts of long lines are joined, markers within those are not taken for section boundaries
+	if len(sections) != 1 || sections[0].Kind != SectionTextDocument || sections[0].End != int64(len(data)) {
+		t.Fatalf("got %+v", sections)
+	}
+	lines := sections[0].Lines
+	if len(lines) != 2 || string(lines[0]) != "/AI11TextDocument" || string(lines[1]) != long {
+		t.Errorf("got lines %q", lines)
+	}
+}
+
+func TestSectionSplitterLastLine(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"terminated", "%AI5_BeginLayer\r%AI5_EndLayer--\r"},
+		{"unterminated", "%AI5_BeginLayer\r%AI5_EndLayer--"},
+		{"unterminated open section", "%AI5_BeginLayer\rlast line"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pd := &closer{lineReader: newLineReader(strings.NewReader(tt.data), false)}
+			ss := NewSectionSplitter(pd)
+			if !ss.Scan() {
+				t.Fatal(ss.Err())
+			}
+			if end := ss.Section().End; end != int64(len(tt.data)) {
+				t.Errorf("got End %d, expected %d", end, len(tt.data))
+			}
+		})
+	}
+}
@@ -1,22 +1,34 @@
 package wasm
 
 import (
+	"context"
+
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
 )
 
 type StreamDicts map[int]*pdfcpu.StreamDict
 type Bitmaps map[int]ImageReader
 
-func extractStreamDicts(ctx *pdfcpu.Context) (scs StreamDicts, bs Bitmaps, err error) {
+// extractStreamDicts splits stream dicts into images and the rest, images are dropped unless withBitmaps.
+// Images are checked against lim once read, the rest right away.
+func extractStreamDicts(ctx context.Context, pdfCtx *pdfcpu.Context, withBitmaps bool, lim *limiter) (scs StreamDicts, bs Bitmaps, err error) {
 	scs = make(StreamDicts)
 	bs = make(Bitmaps)
-	for objId, obj := range ctx.XRefTable.Table {
+	for objId, obj := range pdfCtx.XRefTable.Table {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
 		if obj != nil {
 			dict, isDict := obj.Object.(pdfcpu.StreamDict)
 			if isDict {
 				if subtype := dict.Dict.NameEntry("Subtype"); subtype != nil && *subtype == "Image" {
-					bs[objId] = &imageReader{ctx.XRefTable, objId, dict}
+					if withBitmaps {
+						bs[objId] = &imageReader{pdfCtx.XRefTable, objId, dict, lim}
+					}
 				} else {
+					if err := lim.checkStream(objId, &dict); err != nil {
+						return nil, nil, err
+					}
 					scs[objId] = &dict
 				}
 			}
@@ -0,0 +1,483 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
+	"github.com/pkg/errors"
+)
+
+type ColorModel string
+
+const (
+	ColorModelGray ColorModel = "Gray"
+	ColorModelCMYK ColorModel = "CMYK"
+	ColorModelRGB  ColorModel = "RGB"
+	ColorModelLab  ColorModel = "Lab"
+	// spot colours are printed on their own plate, Color.Alternate tells the model of their process approximation
+	ColorModelSpot     ColorModel = "Spot"
+	ColorModelGradient ColorModel = "Gradient"
+)
+
+// Color is a colour as written by private data paint operators
+type Color struct {
+	Model ColorModel `json:"model"`
+	// Values are components of Model, of Alternate for spot colours
+	Values    []float64  `json:"values,omitempty"`
+	Alternate ColorModel `json:"alternate,omitempty"`
+	// Tint is 1 for full colour, 0 for none - the swatches panel shows it in percents
+	Tint float64 `json:"tint"`
+	// Custom is the name of global or spot colour the value refers to, empty for plain process colours
+	Custom string `json:"custom,omitempty"`
+}
+
+// Swatch is a cell of the document swatches panel
+type Swatch struct {
+	Name string `json:"name"`
+	Color
+	// Global is set for global process colours, editing those updates all the artwork using them
+	Global bool `json:"global"`
+	// Group is the name of colour group the swatch belongs to, empty if none
+	Group string `json:"group,omitempty"`
+	// Gradient is the definition of gradient swatches, nil if it's missing
+	Gradient *Gradient `json:"gradient,omitempty"`
+}
+
+// ColorGroup lists names of swatches grouped together in the swatches panel
+type ColorGroup struct {
+	Name     string   `json:"name"`
+	Swatches []string `json:"swatches"`
+}
+
+type Gradient struct {
+	Name   string         `json:"name"`
+	Radial bool           `json:"radial"`
+	Stops  []GradientStop `json:"stops"`
+}
+
+type GradientStop struct {
+	Color
+	// Location is relative position of the stop on the gradient, from 0 to 1
+	Location float64 `json:"location"`
+	// Midpoint is relative position of the point between this stop and the previous one where colours mix evenly
+	Midpoint float64 `json:"midpoint"`
+}
+
+// SwatchLibrary holds swatches in order of the swatches panel together with colour groups and gradient definitions
+type SwatchLibrary struct {
+	Swatches  []*Swatch     `json:"swatches"`
+	Groups    []*ColorGroup `json:"groups,omitempty"`
+	Gradients []*Gradient   `json:"gradients,omitempty"`
+}
+
+var (
+	// DSC comments of the header declaring global process colours and spot colours, continued by %%+ lines
+	colorDeclarationRe = regexp.MustCompile(`^%%(?:CMYK|RGB)(Process|Custom)Color:`)
+	beginGradientRe    = regexp.MustCompile(`^%AI\d+_BeginGradient`)
+	endGradientRe      = regexp.MustCompile(`^%AI\d+_EndGradient`)
+	beginSwatchesRe    = regexp.MustCompile(`^%AI\d+_BeginSwatches`)
+	endSwatchesRe      = regexp.MustCompile(`^%AI\d+_EndSwatches`)
+	// gradient operators are hidden from PostScript interpreters by %_ prefix
+	hiddenOperatorRe = regexp.MustCompile(`(^|\s)%_`)
+)
+
+// swatchReader keeps state of ReadSwatches between lines
+type swatchReader struct {
+	library SwatchLibrary
+	// process tells for each declared custom colour whether it's global process colour (true) or spot colour (false)
+	process map[string]bool
+	// declaration is the kind of the last colour declaration, continued by %%+ lines
+	declaration string
+	inPalette   bool
+	// inSwatches is set within %AI9_BeginSwatches, which holds palette operators hidden by %_ prefix
+	inSwatches bool
+	group      *ColorGroup
+	current     Color
+	gradient    string
+	// gradientLines collects %AI5_BeginGradient block, its operands span several lines
+	gradientLines [][]byte
+	inGradient    bool
+}
+
+// ReadSwatches returns swatches of the document palette (%AI5_BeginPalette ... %AI5_EndPalette) - cells are set by
+// paint operators followed by `(name) Pc`, colour groups are enclosed in `(name) Pg` ... `PG`. Custom colours are
+// resolved as global or spot through %%CMYKProcessColor, %%CMYKCustomColor and RGB counterparts of the header,
+// gradient swatches through %AI5_BeginGradient blocks. %AI9_BeginSwatches blocks hold the same operators prefixed by %_,
+// swatches and groups of those replace ones of the same name read before. It consumes pd.
+func ReadSwatches(ctx context.Context, pd PrivateData) (*SwatchLibrary, error) {
+	sr := swatchReader{process: map[string]bool{}}
+	continuation := false
+	for pd.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		line := pd.Bytes()
+		lineStart := !continuation
+		continuation = pd.Continued()
+		if sr.inGradient {
+			// ramps of gradients are long hex strings, parts of those are kept as well
+			if lineStart && endGradientRe.Match(line) {
+				if err := sr.parseGradient(); err != nil {
+					return nil, errors.WithMessage(err, "while reading gradient")
+				}
+				continue
+			}
+			if lineStart {
+				sr.gradientLines = append(sr.gradientLines, nil)
+			}
+			last := len(sr.gradientLines) - 1
+			sr.gradientLines[last] = append(sr.gradientLines[last], line...)
+			continue
+		}
+		if !lineStart || len(line) < 2 {
+			continue
+		}
+		if err := sr.parseLine(line); err != nil {
+			return nil, errors.WithMessagef(err, "swatch %q", line)
+		}
+	}
+	if err := pd.Err(); err != nil {
+		return nil, errors.WithMessage(err, "while reading swatches")
+	}
+	sr.resolve()
+	return &sr.library, nil
+}
+
+func (sr *swatchReader) parseLine(line []byte) error {
+	if bytes.HasPrefix(line, []byte("%%")) {
+		return sr.parseDeclaration(line)
+	}
+	switch {
+	case beginGradientRe.Match(line):
+		sr.inGradient = true
+		sr.gradientLines = nil
+		return nil
+	case bytes.HasPrefix(line, []byte("%AI5_BeginPalette")):
+		sr.inPalette = true
+		return nil
+	case bytes.HasPrefix(line, []byte("%AI5_EndPalette")):
+		sr.inPalette = false
+		return nil
+	case beginSwatchesRe.Match(line):
+		sr.inSwatches = true
+		return nil
+	case endSwatchesRe.Match(line):
+		sr.inSwatches, sr.group = false, nil
+		return nil
+	case sr.inSwatches && bytes.HasPrefix(line, []byte("%_")):
+		line = hiddenOperatorRe.ReplaceAll(line, []byte("$1"))
+	case !sr.inPalette && !sr.inSwatches || line[0] == '%':
+		return nil
+	}
+	parser := contents.NewParser(line)
+	for parser.Scan() {
+		if err := sr.paletteOperator(parser.Operator()); err != nil {
+			return err
+		}
+	}
+	return parser.Err()
+}
+
+// parseDeclaration records custom colours of `%%CMYKProcessColor: c m y k (name)` and similar comments
+func (sr *swatchReader) parseDeclaration(line []byte) error {
+	var rest []byte
+	if match := colorDeclarationRe.FindSubmatch(line); match != nil {
+		sr.declaration = string(match[1])
+		rest = line[len(match[0]):]
+	} else if bytes.HasPrefix(line, []byte("%%+")) && sr.declaration != "" {
+		rest = line[len("%%+"):]
+	} else {
+		sr.declaration = ""
+		return nil
+	}
+	// operands without operator are dropped by the parser, so one is made up
+	parser := contents.NewParser(append(append([]byte{}, rest...), " _"...))
+	if !parser.Scan() {
+		return parser.Err()
+	}
+	for _, arg := range parser.Operator().Args {
+		if name, ok := arg.(contents.LiteralString); ok {
+			sr.process[layerName(name.Unescape())] = sr.declaration == "Process"
+		}
+	}
+	return nil
+}
+
+// paletteOperator handles paint operators setting the current colour and palette operators using it
+func (sr *swatchReader) paletteOperator(op contents.Operator) error {
+	switch op.Name {
+	case "Pg":
+		sr.group = sr.colorGroup(firstString(op.Args))
+	case "PG":
+		sr.group = nil
+	case "Pc":
+		swatch := Swatch{Name: firstString(op.Args), Color: sr.current}
+		if swatch.Name == "" {
+			swatch.Name = swatch.Custom
+		}
+		if swatch.Model == ColorModelGradient {
+			swatch.Gradient = &Gradient{Name: sr.gradient}
+		}
+		sr.addSwatch(&swatch)
+	case "Bg":
+		// `flag (name) x y angle length a b c d tx ty Bg` fills with gradient of given name
+		sr.current = Color{Model: ColorModelGradient, Tint: 1}
+		sr.gradient = firstString(op.Args)
+	default:
+		color, ok, err := decodePaint(op)
+		if err != nil {
+			return errors.WithMessagef(err, "operator %s", op.Name)
+		}
+		if ok {
+			sr.current = color
+		}
+	}
+	return nil
+}
+
+// colorGroup returns group of given name, a new one is added if there is none
+func (sr *swatchReader) colorGroup(name string) *ColorGroup {
+	for _, group := range sr.library.Groups {
+		if group.Name == name {
+			return group
+		}
+	}
+	group := &ColorGroup{Name: name}
+	sr.library.Groups = append(sr.library.Groups, group)
+	return group
+}
+
+// addSwatch adds swatch to the library and the current group, a swatch of the same name is replaced in place
+func (sr *swatchReader) addSwatch(swatch *Swatch) {
+	if sr.group != nil {
+		swatch.Group = sr.group.Name
+		if !containsString(sr.group.Swatches, swatch.Name) {
+			sr.group.Swatches = append(sr.group.Swatches, swatch.Name)
+		}
+	}
+	for idx, other := range sr.library.Swatches {
+		if other.Name == swatch.Name {
+			if swatch.Group == "" {
+				swatch.Group = other.Group
+			}
+			sr.library.Swatches[idx] = swatch
+			return
+		}
+	}
+	sr.library.Swatches = append(sr.library.Swatches, swatch)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// decodePaint decodes fill and stroke operators of private data, ok is false for other operators
+func decodePaint(op contents.Operator) (color Color, ok bool, err error) {
+	switch op.Name {
+	case "g", "G":
+		color, err = processColor(ColorModelGray, op.Args, 1)
+	case "k", "K":
+		color, err = processColor(ColorModelCMYK, op.Args, 4)
+	case "Xa", "XA":
+		color, err = processColor(ColorModelRGB, op.Args, 3)
+	case "x", "X", "Xs", "XS", "Xx", "XX":
+		color, err = customColor(op.Args)
+	default:
+		return Color{}, false, nil
+	}
+	return color, err == nil, err
+}
+
+func processColor(model ColorModel, args []contents.Operand, n int) (Color, error) {
+	values, err := numbers(args)
+	if err != nil {
+		return Color{}, err
+	}
+	if len(values) < n {
+		return Color{}, errors.Errorf("%d components of %s expected, got %d", n, model, len(values))
+	}
+	return Color{Model: model, Values: values[len(values)-n:], Tint: 1}, nil
+}
+
+// customColor decodes `c m y k (name) tint` and `values... (name) tint type`, where type is 0 for CMYK, 1 for RGB and
+// 2 for Lab values. Until resolved, colours are taken for spot ones.
+func customColor(args []contents.Operand) (Color, error) {
+	idx := 0
+	for idx < len(args) {
+		if _, ok := args[idx].(contents.LiteralString); ok {
+			break
+		}
+		idx++
+	}
+	if idx == len(args) {
+		return Color{}, errors.New("custom colour name missing")
+	}
+	values, err := numbers(args[:idx])
+	if err != nil {
+		return Color{}, err
+	}
+	params, err := numbers(args[idx+1:])
+	if err != nil {
+		return Color{}, err
+	}
+	if len(params) == 0 {
+		return Color{}, errors.New("custom colour tint missing")
+	}
+	alternate, n := ColorModelCMYK, 4
+	if len(params) > 1 {
+		switch params[1] {
+		case 1:
+			alternate, n = ColorModelRGB, 3
+		case 2:
+			alternate, n = ColorModelLab, 3
+		}
+	}
+	if len(values) < n {
+		return Color{}, errors.Errorf("%d components of %s expected, got %d", n, alternate, len(values))
+	}
+	return Color{
+		Model:     ColorModelSpot,
+		Values:    values[len(values)-n:],
+		Alternate: alternate,
+		Tint:      1 - params[0],
+		Custom:    layerName(args[idx].(contents.LiteralString).Unescape()),
+	}, nil
+}
+
+// parseGradient decodes collected `(name) type count Bd`, `color style midpoint location %_Bs` ... `BD` lines
+func (sr *swatchReader) parseGradient() error {
+	sr.inGradient = false
+	var data []byte
+	for _, line := range sr.gradientLines {
+		if len(line) == 0 || line[0] == '[' && len(bytes.TrimSpace(line)) == 1 {
+			// PostScript mark, closed by BD
+			continue
+		}
+		data = append(data, hiddenOperatorRe.ReplaceAll(line, []byte("$1"))...)
+		data = append(data, '\n')
+	}
+	var gradient *Gradient
+	parser := contents.NewParser(data)
+	for parser.Scan() {
+		op := parser.Operator()
+		switch op.Name {
+		case "Bd":
+			gradient = &Gradient{Name: firstString(op.Args)}
+			if len(op.Args) > 1 {
+				if kind, ok := op.Args[1].(contents.Number); ok {
+					gradient.Radial = kind == 1
+				}
+			}
+		case "Bs":
+			if gradient == nil {
+				return errors.New("gradient stop outside of gradient")
+			}
+			stop, err := gradientStop(op.Args)
+			if err != nil {
+				return errors.WithMessagef(err, "gradient %q stop %d", gradient.Name, len(gradient.Stops))
+			}
+			gradient.Stops = append(gradient.Stops, stop)
+		}
+	}
+	if err := parser.Err(); err != nil {
+		return err
+	}
+	if gradient != nil {
+		sr.library.Gradients = append(sr.library.Gradients, gradient)
+	}
+	return nil
+}
+
+// gradientStop decodes operands of %_Bs, colour style is 0 for gray, 1 for CMYK, 2 for custom CMYK, 3 for RGB written
+// along with CMYK and 4 for custom RGB
+func gradientStop(args []contents.Operand) (GradientStop, error) {
+	if len(args) < 4 {
+		return GradientStop{}, errors.Errorf("%d operands, at least 4 expected", len(args))
+	}
+	params, err := numbers(args[len(args)-3:])
+	if err != nil {
+		return GradientStop{}, err
+	}
+	stop := GradientStop{Midpoint: params[1] / 100, Location: params[2] / 100}
+	colorArgs := args[:len(args)-3]
+	switch params[0] {
+	case 0:
+		stop.Color, err = processColor(ColorModelGray, colorArgs, 1)
+	case 1:
+		stop.Color, err = processColor(ColorModelCMYK, colorArgs, 4)
+	case 2, 4:
+		stop.Color, err = customColor(colorArgs)
+	case 3:
+		stop.Color, err = processColor(ColorModelRGB, colorArgs, 3)
+	default:
+		err = errors.Errorf("unknown colour style %v", params[0])
+	}
+	return stop, err
+}
+
+// resolve turns custom colours declared as process ones into global colours and links gradient definitions
+func (sr *swatchReader) resolve() {
+	gradients := map[string]*Gradient{}
+	for _, gradient := range sr.library.Gradients {
+		for idx := range gradient.Stops {
+			sr.resolveColor(&gradient.Stops[idx].Color)
+		}
+		if gradients[gradient.Name] == nil {
+			gradients[gradient.Name] = gradient
+		}
+	}
+	for _, swatch := range sr.library.Swatches {
+		swatch.Global = sr.resolveColor(&swatch.Color)
+		if swatch.Gradient != nil {
+			swatch.Gradient = gradients[swatch.Gradient.Name]
+		}
+	}
+}
+
+// resolveColor returns true for global process colours
+func (sr *swatchReader) resolveColor(color *Color) bool {
+	if color.Model != ColorModelSpot || !sr.process[color.Custom] {
+		return false
+	}
+	color.Model = color.Alternate
+	color.Alternate = ""
+	return true
+}
+
+func firstString(args []contents.Operand) string {
+	for _, arg := range args {
+		if str, ok := arg.(contents.LiteralString); ok {
+			return layerName(str.Unescape())
+		}
+	}
+	return ""
+}
+
+func numbers(args []contents.Operand) ([]float64, error) {
+	ret := make([]float64, len(args))
+	for idx, arg := range args {
+		number, ok := arg.(contents.Number)
+		if !ok {
+			return nil, errors.Errorf("operand %d is %s, not a number", idx, arg.OperandType())
+		}
+		ret[idx] = float64(number)
+	}
+	return ret, nil
+}
+
+// Swatches reads swatches from IllustratorFile.IndexedPrivateData
+func (f *IllustratorFile) Swatches(ctx context.Context) (*SwatchLibrary, error) {
+	pd, err := f.privateData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer pd.Close()
+	return ReadSwatches(ctx, pd)
+}
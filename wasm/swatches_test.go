@@ -0,0 +1,215 @@
+package wasm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
+)
+
+func TestDecodePaint(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Color
+		ok       bool
+		fails    bool
+	}{
+		{line: "0.5 g", expected: Color{Model: ColorModelGray, Values: []float64{0.5}, Tint: 1}, ok: true},
+		{line: "0 0.5 1 0 K", expected: Color{Model: ColorModelCMYK, Values: []float64{0, 0.5, 1, 0}, Tint: 1}, ok: true},
+		{line: "0.9 0.1 0.1 Xa", expected: Color{Model: ColorModelRGB, Values: []float64{0.9, 0.1, 0.1}, Tint: 1}, ok: true},
+		{
+			line: "0 0.5 1 0 (PANTONE 151 C) 0.25 x",
+			expected: Color{
+				Model: ColorModelSpot, Values: []float64{0, 0.5, 1, 0}, Alternate: ColorModelCMYK, Tint: 0.75, Custom: "PANTONE 151 C",
+			},
+			ok: true,
+		},
+		{
+			line: "1 0 0 (Red) 0 1 Xx",
+			expected: Color{
+				Model: ColorModelSpot, Values: []float64{1, 0, 0}, Alternate: ColorModelRGB, Tint: 1, Custom: "Red",
+			},
+			ok: true,
+		},
+		{
+			line: "50 10 20 (Lab) 0 2 Xx",
+			expected: Color{
+				Model: ColorModelSpot, Values: []float64{50, 10, 20}, Alternate: ColorModelLab, Tint: 1, Custom: "Lab",
+			},
+			ok: true,
+		},
+		{line: "0 0 0 k", fails: true},
+		{line: "0 0 0 1 x", fails: true},
+		{line: "0 0 0 1 (Spot) x", fails: true},
+		{line: "(Name) Pc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			parser := contents.NewParser([]byte(tt.line))
+			if !parser.Scan() {
+				t.Fatal(parser.Err())
+			}
+			color, ok, err := decodePaint(parser.Operator())
+			if tt.fails {
+				if err == nil {
+					t.Errorf("expected error, got %+v", color)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok || !reflect.DeepEqual(color, tt.expected) {
+				t.Errorf("got %+v %v, expected %+v %v", color, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestReadSwatches(t *testing.T) {
+	pd := testPrivateData(
+		"%!PS-Adobe-3.0",
+		"%%CMYKCustomColor: 1 1 1 1 ([Registration])",
+		"%%+ 0 0.5 1 0 (PANTONE 151 C)",
+		"%%CMYKProcessColor: 0.25 0 1 0 (Global Green)",
+		"%%RGBProcessColor: 1 0 0 (Global Red)",
+		"%%EndComments",
+		"%AI5_BeginGradient: (Black, White)",
+		"(Black, White) 0 2 Bd",
+		"[",
+		"<",
+		"FFFEFD",
+		">",
+		"0 %_Br",
+		"0",
+		"0 50 100 %_Bs",
+		"0 0 0 0 1 50 0 %_Bs",
+		"BD",
+		"%AI5_EndGradient",
+		"%AI5_BeginPalette",
+		"0 0 Pb",
+		"1 1 1 1 ([Registration]) 0 Xs",
+		"([Registration]) Pc",
+		"0 0 0 0 k",
+		"(White) Pc",
+		"0.25 0 1 0 (Global Green) 0.5 Xs",
+		"(Global Green) Pc",
+		"(Reds) Pg",
+		"1 0 0 (Global Red) 0 1 Xx",
+		"Pc",
+		"0.9 0.1 0.1 Xa",
+		"(R=230) Pc",
+		"PG",
+		"0 Bb",
+		"2 (Black, White) -4014 4716 0 0 1 0 0 1 0 0 Bg",
+		"0 BB",
+		"(Black, White) Pc",
+		"0 0.5 1 0 (PANTONE 151 C) 0 x",
+		"Pc",
+		"PB",
+		"%AI5_EndPalette",
+		"0 0 0 1 k",
+		"(Outside) Pc",
+	)
+	library, err := ReadSwatches(context.Background(), pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gradient := &Gradient{
+		Name: "Black, White",
+		Stops: []GradientStop{
+			{Color: Color{Model: ColorModelGray, Values: []float64{0}, Tint: 1}, Midpoint: 0.5, Location: 1},
+			{Color: Color{Model: ColorModelCMYK, Values: []float64{0, 0, 0, 0}, Tint: 1}, Midpoint: 0.5},
+		},
+	}
+	expected := &SwatchLibrary{
+		Swatches: []*Swatch{
+			{
+				Name: "[Registration]",
+				Color: Color{
+					Model: ColorModelSpot, Values: []float64{1, 1, 1, 1}, Alternate: ColorModelCMYK, Tint: 1, Custom: "[Registration]",
+				},
+			},
+			{Name: "White", Color: Color{Model: ColorModelCMYK, Values: []float64{0, 0, 0, 0}, Tint: 1}},
+			{
+				Name:   "Global Green",
+				Color:  Color{Model: ColorModelCMYK, Values: []float64{0.25, 0, 1, 0}, Tint: 0.5, Custom: "Global Green"},
+				Global: true,
+			},
+			{
+				Name:   "Global Red",
+				Color:  Color{Model: ColorModelRGB, Values: []float64{1, 0, 0}, Tint: 1, Custom: "Global Red"},
+				Global: true,
+				Group:  "Reds",
+			},
+			{Name: "R=230", Color: Color{Model: ColorModelRGB, Values: []float64{0.9, 0.1, 0.1}, Tint: 1}, Group: "Reds"},
+			{Name: "Black, White", Color: Color{Model: ColorModelGradient, Tint: 1}, Gradient: gradient},
+			{
+				Name: "PANTONE 151 C",
+				Color: Color{
+					Model: ColorModelSpot, Values: []float64{0, 0.5, 1, 0}, Alternate: ColorModelCMYK, Tint: 1, Custom: "PANTONE 151 C",
+				},
+			},
+		},
+		Groups:    []*ColorGroup{{Name: "Reds", Swatches: []string{"Global Red", "R=230"}}},
+		Gradients: []*Gradient{gradient},
+	}
+	if !reflect.DeepEqual(library, expected) {
+		t.Errorf("got %+v, expected %+v", library, expected)
+	}
+}
+
+func TestReadSwatchesBlock(t *testing.T) {
+	pd := testPrivateData(
+		"%%CMYKProcessColor: 0.25 0 1 0 (Global Green)",
+		"%%EndComments",
+		"%AI5_BeginPalette",
+		"0 0 Pb",
+		"0 0 0 0 k",
+		"(White) Pc",
+		"0 0 0 1 k",
+		"(Black) Pc",
+		"PB",
+		"%AI5_EndPalette",
+		"%AI9_BeginSwatches",
+		"%_0 0 0 0 k",
+		"%_(White) Pc",
+		"%_(Greens) Pg",
+		"%_0.25 0 1 0 (Global Green) 0 Xs",
+		"%_(Global Green) Pc",
+		"%_0.5 0 0.3 0 (Dark Green) 0.2 0 Xs",
+		"%_(Dark Green) Pc",
+		"%_PG",
+		"%_0.5 0.5 0.5 Xa (Gray) Pc",
+		"%AI9_EndSwatches",
+	)
+	library, err := ReadSwatches(context.Background(), pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &SwatchLibrary{
+		Swatches: []*Swatch{
+			{Name: "White", Color: Color{Model: ColorModelCMYK, Values: []float64{0, 0, 0, 0}, Tint: 1}},
+			{Name: "Black", Color: Color{Model: ColorModelCMYK, Values: []float64{0, 0, 0, 1}, Tint: 1}},
+			{
+				Name:   "Global Green",
+				Color:  Color{Model: ColorModelCMYK, Values: []float64{0.25, 0, 1, 0}, Tint: 1, Custom: "Global Green"},
+				Global: true,
+				Group:  "Greens",
+			},
+			{
+				Name: "Dark Green",
+				Color: Color{
+					Model: ColorModelSpot, Values: []float64{0.5, 0, 0.3, 0}, Alternate: ColorModelCMYK, Tint: 0.8, Custom: "Dark Green",
+				},
+				Group: "Greens",
+			},
+			{Name: "Gray", Color: Color{Model: ColorModelRGB, Values: []float64{0.5, 0.5, 0.5}, Tint: 1}},
+		},
+		Groups: []*ColorGroup{{Name: "Greens", Swatches: []string{"Global Green", "Dark Green"}}},
+	}
+	if !reflect.DeepEqual(library, expected) {
+		t.Errorf("got %+v, expected %+v", library, expected)
+	}
+}
@@ -0,0 +1,374 @@
+package wasm
+
+import (
+	"context"
+	"math"
+	"regexp"
+
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// Symbol is a %AI10_BeginSymbol ... %AI10_EndSymbol definition
+type Symbol struct {
+	Name string `json:"name"`
+}
+
+// SymbolInstance is a %AI10_BeginSymbolInstance ... %AI10_EndSymbolInstance placement of a symbol
+type SymbolInstance struct {
+	Symbol string `json:"symbol"`
+	// Transform is the [a b c d tx ty] matrix of the placement as written in private data
+	Transform []float64 `json:"transform"`
+	// Parent is the name of symbol definition the instance is nested in, empty for instances in artwork
+	Parent string `json:"parent,omitempty"`
+	// XObject is object number of the Form XObject drawing the instance, 0 if it was not matched - see IllustratorFile.Symbols
+	XObject int `json:"xObject,omitempty"`
+	// Artboard is index of the artboard drawing XObject
+	Artboard int `json:"artboard,omitempty"`
+	// CTM is the current transformation matrix XObject is drawn with
+	CTM []float64 `json:"ctm,omitempty"`
+}
+
+// SymbolLibrary holds symbol definitions and instances in order of private data
+type SymbolLibrary struct {
+	Symbols   []*Symbol         `json:"symbols"`
+	Instances []*SymbolInstance `json:"instances"`
+}
+
+var symbolMarkerRe = regexp.MustCompile(`^%AI\d+_(Begin|End)(SymbolInstance|Symbol)\b`)
+
+// ReadSymbols returns symbol definitions and instances of private data. The symbol name is the first string of a block,
+// instance transform its first array of 6 numbers. It consumes pd.
+func ReadSymbols(ctx context.Context, pd PrivateData) (*SymbolLibrary, error) {
+	var library SymbolLibrary
+	// definitions holds symbols being defined, those can nest instances of other symbols
+	var definitions []*Symbol
+	var instance *SymbolInstance
+	continuation := false
+	for pd.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		line := pd.Bytes()
+		lineStart := !continuation
+		continuation = pd.Continued()
+		if !lineStart || len(line) < 2 {
+			continue
+		}
+		if match := symbolMarkerRe.FindSubmatch(line); match != nil {
+			begin, kind := string(match[1]) == "Begin", string(match[2])
+			switch {
+			case begin && kind == "Symbol":
+				definitions = append(definitions, &Symbol{})
+			case begin:
+				instance = &SymbolInstance{}
+				if len(definitions) > 0 {
+					instance.Parent = definitions[len(definitions)-1].Name
+				}
+			case kind == "Symbol" && len(definitions) > 0:
+				library.Symbols = append(library.Symbols, definitions[len(definitions)-1])
+				definitions = definitions[:len(definitions)-1]
+			case kind == "SymbolInstance" && instance != nil:
+				library.Instances = append(library.Instances, instance)
+				instance = nil
+			}
+			continue
+		}
+		if line[0] == '%' {
+			continue
+		}
+		if instance != nil {
+			if instance.Symbol == "" || instance.Transform == nil {
+				instance.parseRecord(line)
+			}
+		} else if len(definitions) > 0 && definitions[len(definitions)-1].Name == "" {
+			definitions[len(definitions)-1].Name = recordString(line)
+		}
+	}
+	if err := pd.Err(); err != nil {
+		return nil, errors.WithMessage(err, "while reading symbols")
+	}
+	return &library, nil
+}
+
+// parseRecord picks name and transform of the instance, lines which are not content stream syntax are skipped
+func (instance *SymbolInstance) parseRecord(line []byte) {
+	parser := contents.NewParser(line)
+	for parser.Scan() {
+		for _, arg := range parser.Operator().Args {
+			switch arg := arg.(type) {
+			case contents.LiteralString:
+				if instance.Symbol == "" {
+					instance.Symbol = layerName(arg.Unescape())
+				}
+			case contents.Array:
+				if values, err := numbers(arg); err == nil && len(values) == 6 && instance.Transform == nil {
+					instance.Transform = values
+				}
+			}
+		}
+	}
+}
+
+// recordString returns the first string of a line, empty if there is none
+func recordString(line []byte) string {
+	parser := contents.NewParser(line)
+	for parser.Scan() {
+		if name := firstString(parser.Operator().Args); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// xObjectUse is an XObject painted on an artboard
+type xObjectUse struct {
+	objNr    int
+	artboard int
+	// ctm is the current transformation matrix of the page the XObject is painted with, Form XObjects included
+	ctm  []float64
+	dict pdfcpu.Dict
+}
+
+// Symbols reads symbols from private data and links instances in artwork to Form XObjects of StreamDicts, those
+// nested in other Form XObjects included, see matchPlacements. Private data is read from IllustratorFile.IndexedPrivateData.
+func (f *IllustratorFile) Symbols(ctx context.Context) (*SymbolLibrary, error) {
+	pd, err := f.privateData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	library, err := ReadSymbols(ctx, pd)
+	pd.Close()
+	if err != nil {
+		return nil, err
+	}
+	if f.SerializedFile == nil {
+		return library, nil
+	}
+	uses, err := f.xObjectUses(ctx, "Form")
+	if err != nil {
+		return nil, errors.WithMessage(err, "while linking symbol instances")
+	}
+	// instances nested in symbol definitions are placed relative to the symbol, not the artboard
+	placements := make([][]float64, len(library.Instances))
+	for idx, instance := range library.Instances {
+		if instance.Parent == "" && len(instance.Transform) == 6 {
+			placements[idx] = flipY(instance.Transform)
+		}
+	}
+	matrices := make([][]float64, len(uses))
+	for idx, use := range uses {
+		matrices[idx] = multiply(formMatrix(use.dict), use.ctm)
+	}
+	for idx, match := range matchPlacements(placements, uses, matrices, nil) {
+		if match >= 0 {
+			instance := library.Instances[idx]
+			instance.XObject = uses[match].objNr
+			instance.Artboard = uses[match].artboard
+			instance.CTM = uses[match].ctm
+		}
+	}
+	return library, nil
+}
+
+// flipY converts matrix of private data, where y axis points down, to PDF space up to translation by the artboard origin
+func flipY(m []float64) []float64 {
+	return []float64{m[0], -m[1], -m[2], m[3], m[4], -m[5]}
+}
+
+// matchPlacements links placements of private data to uses of XObjects, returning index of use for each placement or
+// -1. Placements are PDF matrices expected for the uses, up to translation by the origin of artboard - nil ones are
+// skipped. matrices hold the matrix of each use, accept filters uses further, e.g. by image dimensions, if not nil.
+// The origin of each artboard is the translation most placements and uses of the same linear part agree on, placements
+// take the first free use at the very place first, then the first free use of the same linear part, in order.
+func matchPlacements(placements [][]float64, uses []xObjectUse, matrices [][]float64, accept func(placement, use int) bool) []int {
+	candidate := func(placement, use int) bool {
+		return sameLinearPart(placements[placement], matrices[use]) && (accept == nil || accept(placement, use))
+	}
+	type origin struct {
+		artboard int
+		x, y     float64
+	}
+	votes := map[origin]int{}
+	best := map[int]origin{}
+	for placement := range placements {
+		if placements[placement] == nil {
+			continue
+		}
+		for use := range uses {
+			if !candidate(placement, use) {
+				continue
+			}
+			o := origin{
+				artboard: uses[use].artboard,
+				x:        math.Round(matrices[use][4]-placements[placement][4]),
+				y:        math.Round(matrices[use][5]-placements[placement][5]),
+			}
+			votes[o]++
+			if b, ok := best[o.artboard]; !ok || votes[o] > votes[b] {
+				best[o.artboard] = o
+			}
+		}
+	}
+
+	matches := make([]int, len(placements))
+	used := make([]bool, len(uses))
+	assign := func(match func(placement, use int) bool) {
+		for placement := range placements {
+			if placements[placement] == nil || matches[placement] >= 0 {
+				continue
+			}
+			for use := range uses {
+				if !used[use] && candidate(placement, use) && match(placement, use) {
+					matches[placement] = use
+					used[use] = true
+					break
+				}
+			}
+		}
+	}
+	for idx := range matches {
+		matches[idx] = -1
+	}
+	assign(func(placement, use int) bool {
+		o := best[uses[use].artboard]
+		return math.Abs(matrices[use][4]-placements[placement][4]-o.x) <= 1 && math.Abs(matrices[use][5]-placements[placement][5]-o.y) <= 1
+	})
+	assign(func(placement, use int) bool { return true })
+	return matches
+}
+
+// xObjectUses returns XObjects of given subtype painted by content streams of artboards, in order of painting. Form
+// XObjects are walked into, so XObjects they paint are returned too.
+func (f *IllustratorFile) xObjectUses(ctx context.Context, subtype string) ([]xObjectUse, error) {
+	if f.SerializedFile == nil {
+		return nil, errors.New("XObject uses require serialized file")
+	}
+	artboards, err := f.Artboards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	xRefTable := &f.SerializedFile.XRefTable
+	var uses []xObjectUse
+	// forms holds Form XObjects being walked, so that cyclic ones are not walked into again
+	forms := map[int]bool{}
+	var walk func(nodes []contents.Node, resources pdfcpu.Dict, base []float64, artboard int) error
+	walk = func(nodes []contents.Node, resources pdfcpu.Dict, base []float64, artboard int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		xObjects, err := xRefTable.DereferenceDict(resources["XObject"])
+		if err != nil {
+			return errors.WithMessage(err, "XObject")
+		}
+		for _, node := range nodes {
+			switch node := node.(type) {
+			case *contents.MarkedContext:
+				if err := walk(node.Kids, resources, base, artboard); err != nil {
+					return err
+				}
+			case *contents.XObject:
+				ref, ok := xObjects[node.Name].(pdfcpu.IndirectRef)
+				if !ok {
+					continue
+				}
+				sd, _, err := xRefTable.DereferenceStreamDict(ref)
+				if err != nil || sd == nil {
+					continue
+				}
+				objNr := ref.ObjectNumber.Value()
+				ctm := multiply(node.GraphicsState.CTM, base)
+				name := sd.Dict.NameEntry("Subtype")
+				if name != nil && *name == subtype {
+					uses = append(uses, xObjectUse{objNr, artboard, ctm, sd.Dict})
+				}
+				if name == nil || *name != "Form" || forms[objNr] {
+					continue
+				}
+				formResources, err := xRefTable.DereferenceDict(sd.Dict["Resources"])
+				if err != nil {
+					return errors.WithMessagef(err, "Form XObject %d Resources", objNr)
+				}
+				if formResources == nil {
+					// resources of the painting stream apply to forms without their own
+					formResources = resources
+				}
+				kids, err := f.Scene(objNr, formResources, false)
+				if err != nil {
+					return errors.WithMessagef(err, "Form XObject %d", objNr)
+				}
+				forms[objNr] = true
+				err = walk(kids, formResources, multiply(formMatrix(sd.Dict), ctm), artboard)
+				delete(forms, objNr)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for _, artboard := range artboards {
+		for _, objNr := range artboard.Contents {
+			nodes, err := f.Scene(objNr, artboard.Resources, false)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "artboard %d content stream %d", artboard.Idx, objNr)
+			}
+			if err := walk(nodes, artboard.Resources, nil, artboard.Idx); err != nil {
+				return nil, errors.WithMessagef(err, "artboard %d", artboard.Idx)
+			}
+		}
+	}
+	return uses, nil
+}
+
+// formMatrix returns Matrix of Form XObject dict, nil (identity) if it has none
+func formMatrix(dict pdfcpu.Dict) []float64 {
+	arr := dict.ArrayEntry("Matrix")
+	if len(arr) != 6 {
+		return nil
+	}
+	m := make([]float64, 6)
+	for idx, obj := range arr {
+		switch val := obj.(type) {
+		case pdfcpu.Integer:
+			m[idx] = float64(val.Value())
+		case pdfcpu.Float:
+			m[idx] = val.Value()
+		default:
+			return nil
+		}
+	}
+	return m
+}
+
+// multiply returns m1 × m2 of [a b c d e f] matrices, i.e. m1 applied first - nil stands for identity
+func multiply(m1, m2 []float64) []float64 {
+	if len(m1) != 6 {
+		m1 = []float64{1, 0, 0, 1, 0, 0}
+	}
+	if len(m2) != 6 {
+		return m1
+	}
+	return []float64{
+		m1[0]*m2[0] + m1[1]*m2[2],
+		m1[0]*m2[1] + m1[1]*m2[3],
+		m1[2]*m2[0] + m1[3]*m2[2],
+		m1[2]*m2[1] + m1[3]*m2[3],
+		m1[4]*m2[0] + m1[5]*m2[2] + m2[4],
+		m1[4]*m2[1] + m1[5]*m2[3] + m2[5],
+	}
+}
+
+// sameLinearPart compares a, b, c and d of two matrices
+func sameLinearPart(m1, m2 []float64) bool {
+	if len(m1) < 4 || len(m2) < 4 {
+		return false
+	}
+	for idx := 0; idx < 4; idx++ {
+		if math.Abs(m1[idx]-m2[idx]) > 1e-3 {
+			return false
+		}
+	}
+	return true
+}
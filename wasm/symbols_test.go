@@ -0,0 +1,136 @@
+package wasm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+var testArtboardSetup = []string{
+	"%_/ArtboardArray :",
+	"%_/Dictionary :",
+	"%_(Main) /UnicodeString (Name) ,",
+	"%_0 0 /RealPoint (PositionPoint1) ,",
+	"%_100 -100 /RealPoint (PositionPoint2) ,",
+	"%_; ,",
+	"%_; (ArtboardArray) ,",
+}
+
+func TestReadSymbols(t *testing.T) {
+	pd := testPrivateData(
+		"%AI10_BeginSymbol",
+		"(Star) 1 Sym",
+		"%AI10_BeginSymbolInstance",
+		"(Dot) [1 0 0 1 0 0] Xi",
+		"%AI10_EndSymbolInstance",
+		"%AI10_EndSymbol",
+		"%AI10_BeginSymbol",
+		"(Dot) 1 Sym",
+		"%AI10_EndSymbol",
+		"%AI10_BeginSymbolInstance",
+		"(Star) [2 0 0 2 5 5] Xi",
+		"%AI10_EndSymbolInstance",
+	)
+	library, err := ReadSymbols(context.Background(), pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &SymbolLibrary{
+		Symbols: []*Symbol{{Name: "Star"}, {Name: "Dot"}},
+		Instances: []*SymbolInstance{
+			{Symbol: "Dot", Transform: []float64{1, 0, 0, 1, 0, 0}, Parent: "Star"},
+			{Symbol: "Star", Transform: []float64{2, 0, 0, 2, 5, 5}},
+		},
+	}
+	if !reflect.DeepEqual(library, expected) {
+		t.Errorf("got %+v, expected %+v", library, expected)
+	}
+}
+
+func TestSymbolsKeepPrivateData(t *testing.T) {
+	privateData := append([]string{
+		"%AI10_BeginSymbol",
+		"(Star) 1 Sym",
+		"%AI10_EndSymbol",
+		"%AI10_BeginSymbolInstance",
+		"(Star) [1 0 0 1 10 20] Xi",
+		"%AI10_EndSymbolInstance",
+		"%AI10_BeginSymbolInstance",
+		"(Star) [2 0 0 2 5 5] Xi",
+		"%AI10_EndSymbolInstance",
+	}, testArtboardSetup...)
+	form := testStream("/Type /XObject /Subtype /Form /BBox [0 0 10 10]", "0 0 m 10 10 l S")
+	f := testIllustratorFile(t, privateData, "0 0 100 100", "/XObject << /X0 9 0 R /X1 10 0 R >>",
+		"q 1 0 0 1 10 80 cm /X0 Do Q q 0.5 0 0 0.5 0 0 cm /X1 Do Q q 2 0 0 2 5 95 cm /X0 Do Q", form, form)
+	ctx := context.Background()
+
+	// either order of calls and repeated calls give the same results
+	for run := 0; run < 2; run++ {
+		library, err := f.Symbols(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(library.Symbols) != 1 || len(library.Instances) != 2 {
+			t.Fatalf("run %d got %d symbols and %d instances", run, len(library.Symbols), len(library.Instances))
+		}
+		for idx, ctm := range [][]float64{{1, 0, 0, 1, 10, 80}, {2, 0, 0, 2, 5, 95}} {
+			instance := library.Instances[idx]
+			if instance.XObject != 9 || !reflect.DeepEqual(instance.CTM, ctm) {
+				t.Errorf("run %d instance %d got XObject %d CTM %v", run, idx, instance.XObject, instance.CTM)
+			}
+		}
+		artboards, err := f.Artboards(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if artboards[0].Name != "Main" || artboards[0].Setup == nil {
+			t.Errorf("run %d got artboard %q with setup %v", run, artboards[0].Name, artboards[0].Setup)
+		}
+	}
+}
+
+func TestSymbolsLinking(t *testing.T) {
+	// private data has y axis pointing down, the artboard origin is at 0 100 in PDF
+	privateData := append([]string{
+		"%AI10_BeginSymbol",
+		"(Star) 1 Sym",
+		"%AI10_EndSymbol",
+		"%AI10_BeginSymbolInstance",
+		"(Star) [1 0 0 1 60 40] Xi",
+		"%AI10_EndSymbolInstance",
+		"%AI10_BeginSymbolInstance",
+		"(Star) [1 0 0 1 10 20] Xi",
+		"%AI10_EndSymbolInstance",
+		"%AI10_BeginSymbolInstance",
+		"(Star) [0 1 -1 0 30 30] Xi",
+		"%AI10_EndSymbolInstance",
+	}, testArtboardSetup...)
+	form := testStream("/Type /XObject /Subtype /Form /BBox [0 0 10 10]", "0 0 m 10 10 l S")
+	// the rotated instance is painted by a group, which is a Form XObject itself
+	group := testStream("/Type /XObject /Subtype /Form /BBox [0 0 100 100] /Resources << /XObject << /X1 10 0 R >> >>",
+		"q 0 -1 1 0 30 70 cm /X1 Do Q")
+	f := testIllustratorFile(t, privateData, "0 0 100 100", "/XObject << /X0 9 0 R /G 11 0 R >>",
+		"q 1 0 0 1 10 80 cm /X0 Do Q /G Do q 1 0 0 1 60 60 cm /X0 Do Q", form, form, group)
+
+	library, err := f.Symbols(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		xObject int
+		ctm     []float64
+	}{
+		{9, []float64{1, 0, 0, 1, 60, 60}},
+		{9, []float64{1, 0, 0, 1, 10, 80}},
+		{10, []float64{0, -1, 1, 0, 30, 70}},
+	}
+	if len(library.Instances) != len(expected) {
+		t.Fatalf("got %d instances, expected %d", len(library.Instances), len(expected))
+	}
+	for idx, instance := range library.Instances {
+		if instance.XObject != expected[idx].xObject || !reflect.DeepEqual(instance.CTM, expected[idx].ctm) {
+			t.Errorf("instance %d got XObject %d CTM %v, expected XObject %d CTM %v",
+				idx, instance.XObject, instance.CTM, expected[idx].xObject, expected[idx].ctm)
+		}
+	}
+}
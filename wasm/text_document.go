@@ -0,0 +1,218 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/ascii85"
+	"io"
+	"unicode/utf16"
+
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
+	"github.com/pkg/errors"
+)
+
+// TextFrame is the size of area text frame, point text has none
+type TextFrame struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// TextLayerRecord is Go counterpart of TextLayerRecord from src/private-data/text-document/interfaces.ts
+type TextLayerRecord struct {
+	Content string     `json:"content"`
+	Index   int        `json:"index"`
+	Frame   *TextFrame `json:"frame,omitempty"`
+	// Paragraphs and Styles cover content including the trailing \r dropped from Content
+	Paragraphs []ParagraphRun `json:"paragraphs,omitempty"`
+	Styles     []StyleRun     `json:"styles,omitempty"`
+}
+
+type textEncoding string
+
+const (
+	textEncodingASCII85 textEncoding = "ascii85"
+	textEncodingUnknown textEncoding = "unknown"
+)
+
+// textDocument holds a single encoded stream of TextDocument section, e.g. /AI11TextDocument or /AI11UndoFreeTextDocument
+type textDocument struct {
+	encoding textEncoding
+	content  []byte
+}
+
+// decode returns content decoded via known encoding - so far only ASCII85 has been seen in TextDocument
+func (td *textDocument) decode() ([]byte, error) {
+	if td.encoding != textEncodingASCII85 {
+		return td.content, nil
+	}
+	data := bytes.TrimSpace(td.content)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if end := bytes.Index(data, []byte("~>")); end >= 0 {
+		data = data[:end]
+	}
+	ret, err := io.ReadAll(ascii85.NewDecoder(bytes.NewReader(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, "while decoding ASCII85")
+	}
+	return ret, nil
+}
+
+// splitTextDocuments mirrors handleTextDocumentLine from src/private-data/section-splitter.ts
+func splitTextDocuments(section *Section) []*textDocument {
+	var docs []*textDocument
+	var current *textDocument
+	for _, line := range section.Lines {
+		if len(line) == 0 {
+			continue
+		}
+		switch line[0] {
+		case '/':
+			if !bytes.HasPrefix(line, []byte("/AI11TextDocument")) && !bytes.HasPrefix(line, []byte("/AI11UndoFreeTextDocument")) {
+				continue
+			}
+			current = &textDocument{encoding: textEncodingUnknown}
+			for _, field := range bytes.Split(line, []byte(" ")) {
+				if string(field) == "/ASCII85Decode" {
+					current.encoding = textEncodingASCII85
+				}
+			}
+			docs = append(docs, current)
+		case '%':
+			if current != nil {
+				current.content = append(current.content, line[1:]...)
+			}
+		}
+	}
+	return docs
+}
+
+// ReadTextLayers consumes pd and returns content of text layers found in the first TextDocument section -
+// Go counterpart of Parser.loadTextLayers from src/private-data/parser.ts.
+func ReadTextLayers(ctx context.Context, pd PrivateData) ([]TextLayerRecord, error) {
+	var section *Section
+	ss := NewSectionSplitter(pd, SectionTextDocument)
+	for ss.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if s := ss.Section(); s.Kind == SectionTextDocument && section == nil {
+			section = s
+		}
+	}
+	if err := ss.Err(); err != nil {
+		return nil, errors.WithMessage(err, "while splitting private data")
+	}
+	if section == nil {
+		return nil, errors.New("TextDocument entity is missing in the private data section")
+	}
+	docs := splitTextDocuments(section)
+	if len(docs) < 1 {
+		return nil, errors.New("TextDocument entity is missing in the private data section")
+	}
+	decoded, err := docs[0].decode()
+	if err != nil {
+		return nil, errors.WithMessage(err, "while decoding TextDocument")
+	}
+	dict, err := contents.ParseDict(decoded)
+	if err != nil {
+		return nil, errors.WithMessage(err, "while parsing TextDocument")
+	}
+	return extractTextLayers(dict)
+}
+
+// TextLayers reads text layers of the first TextDocument section of indexed private data, see ReadTextLayers
+func (f *IllustratorFile) TextLayers(ctx context.Context) ([]TextLayerRecord, error) {
+	indexed, err := f.IndexedPrivateData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, section := range indexed.Sections() {
+		if section.Kind == SectionTextDocument {
+			pd := indexed.SectionReader(ctx, section)
+			defer pd.Close()
+			return ReadTextLayers(ctx, pd)
+		}
+	}
+	return nil, errors.New("TextDocument entity is missing in the private data section")
+}
+
+// extractTextLayers mirrors extractTextLayersContent from src/private-data/text-document/index.ts
+func extractTextLayers(textDocument contents.Dict) ([]TextLayerRecord, error) {
+	layers, _ := tdDict(textDocument, tdKeyObjects)["1"].(contents.Array)
+	styles := newTextStyles(textDocument)
+	ret := make([]TextLayerRecord, 0, len(layers))
+	for idx, operand := range layers {
+		layer, ok := operand.(contents.Dict)
+		if !ok {
+			return nil, errors.Errorf("text layer %d is not a dict, is '%s'", idx, operand.OperandType())
+		}
+		content, err := textLayerContent(layer)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "text layer %d", idx)
+		}
+		model := tdDict(layer, "0")
+		ret = append(ret, TextLayerRecord{
+			Content:    content,
+			Index:      idx,
+			Frame:      textLayerFrame(layer),
+			Paragraphs: styles.paragraphRuns(model),
+			Styles:     styles.styleRuns(model),
+		})
+	}
+	return ret, nil
+}
+
+func textLayerContent(layer contents.Dict) (string, error) {
+	str, ok := tdDict(layer, "0")[tdKeyText].(contents.LiteralString)
+	if !ok {
+		return "", errors.New("missing text content")
+	}
+	content, err := decodeUTF16BE(str.UnescapeBinary())
+	if err != nil {
+		return "", err
+	}
+	if n := len(content); n > 0 && content[n-1] == '\r' {
+		content = content[:n-1]
+	}
+	return content, nil
+}
+
+// layer /1 /2 [0] /6 [0] /1 holds [x y width height] of area text
+func textLayerFrame(layer contents.Dict) *TextFrame {
+	view, _ := layer["1"].(contents.Dict)
+	frames, _ := view["2"].(contents.Array)
+	if len(frames) < 1 {
+		return nil
+	}
+	frame, _ := frames[0].(contents.Dict)
+	paths, _ := frame["6"].(contents.Array)
+	if len(paths) < 1 {
+		return nil
+	}
+	path, _ := paths[0].(contents.Dict)
+	rect, _ := path["1"].(contents.Array)
+	if len(rect) != 4 {
+		return nil
+	}
+	width, ok := rect[2].(contents.Number)
+	if !ok {
+		return nil
+	}
+	height, ok := rect[3].(contents.Number)
+	if !ok {
+		return nil
+	}
+	return &TextFrame{Width: float64(width), Height: float64(height)}
+}
+
+func decodeUTF16BE(data []byte) (string, error) {
+	if len(data) < 2 || data[0] != 0xfe || data[1] != 0xff {
+		return "", errors.New("BOM in text document is not BE")
+	}
+	data = data[2:]
+	units := make([]uint16, len(data)/2)
+	for idx := range units {
+		units[idx] = uint16(data[2*idx])<<8 | uint16(data[2*idx+1])
+	}
+	return string(utf16.Decode(units)), nil
+}
@@ -0,0 +1,126 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/ascii85"
+	"testing"
+
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
+)
+
+func TestTextLayerContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected string
+	}{
+		{"plain", "\xfe\xff\x00H\x00i", "Hi"},
+		{"parentheses", "\xfe\xff\x00\\(\x00\\)", "()"},
+		{"backslash", "\xfe\xff\x00\\\\", `\`},
+		{"escaped line breaks", "\xfe\xff\x00a\x00\\r\x00b\x00\\n\x00c\x00\\t", "a\rb\nc\t"},
+		{"octal", "\xfe\xff\\000A\\000\\102\\0\\103", "ABC"},
+		{"raw carriage return", "\xfe\xff\x00a\x00\r\x00b", "a\rb"},
+		{"trailing carriage return", "\xfe\xff\x00a\x00\\r", "a"},
+		{"raw backslash within character", "\xfe\xff\\\\\x0d", "對"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer := contents.Dict{"0": contents.Dict{tdKeyText: contents.LiteralString(tt.raw)}}
+			content, err := textLayerContent(layer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if content != tt.expected {
+				t.Errorf("got %q, expected %q", content, tt.expected)
+			}
+		})
+	}
+}
+
+// testTextDocumentLines returns TextDocument section of private data holding decoded, ASCII85 encoded
+func testTextDocumentLines(decoded string) []string {
+	encoded := make([]byte, ascii85.MaxEncodedLen(len(decoded)))
+	encoded = encoded[:ascii85.Encode(encoded, []byte(decoded))]
+	lines := []string{"%AI11_BeginTextDocument", "/AI11TextDocument : /ASCII85Decode ,"}
+	for len(encoded) > 0 {
+		n := 70
+		if n > len(encoded) {
+			n = len(encoded)
+		}
+		lines = append(lines, "%"+string(encoded[:n]))
+		encoded = encoded[n:]
+	}
+	return append(lines, "%~>", "%AI11_EndTextDocument")
+}
+
+func TestSplitTextDocuments(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected []string
+	}{
+		{"ascii85", []string{"/AI11TextDocument : /ASCII85Decode ,", "%<~87cURD]", "%j7BEbo7~>"}, []string{"Hello world"}},
+		{"whitespace and no brackets", []string{"/AI11TextDocument : /ASCII85Decode ,", "% 87cUR", "%D]j7BEbo7 ~>"}, []string{"Hello world"}},
+		{"unknown encoding", []string{"/AI11TextDocument : /Raw ,", "%/0 1"}, []string{"/0 1"}},
+		{"undo document", []string{
+			"/AI11TextDocument : /ASCII85Decode ,", "%87cURD]j7BEbo7~>",
+			"/AI11UndoFreeTextDocument : /ASCII85Decode ,", "%z~>",
+		}, []string{"Hello world", "\x00\x00\x00\x00"}},
+		{"other entries", []string{"/AI11TextRange : /ASCII85Decode ,", "%87cURD]j7BEbo7~>", "", "1 0 0 1 0 0 Tm"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			section := Section{Kind: SectionTextDocument}
+			for _, line := range tt.lines {
+				section.Lines = append(section.Lines, []byte(line))
+			}
+			docs := splitTextDocuments(&section)
+			if len(docs) != len(tt.expected) {
+				t.Fatalf("got %d documents, expected %d", len(docs), len(tt.expected))
+			}
+			for idx, doc := range docs {
+				decoded, err := doc.decode()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(decoded) != tt.expected[idx] {
+					t.Errorf("document %d got %q, expected %q", idx, decoded, tt.expected[idx])
+				}
+			}
+		})
+	}
+	malformed := textDocument{encoding: textEncodingASCII85, content: []byte("87cU{~>")}
+	if decoded, err := malformed.decode(); err == nil {
+		t.Errorf("malformed ASCII85 decoded as %q", decoded)
+	}
+}
+
+func TestReadTextLayers(t *testing.T) {
+	decoded := "/0 << >> /1 << /1 [ << /0 << /0 (\xfe\xff\x00H\x00i\x00\\r) >> >> " +
+		"<< /0 << /0 (\xfe\xff\x00a\x00\\(\x00b) >> /1 << /2 [ << /6 [ << /1 [ 0 0 120.5 40 ] >> ] >> ] >> >> ] >>"
+	lines := append([]string{"%!PS-Adobe-3.0", "%AI5_BeginLayer"}, testTextDocumentLines(decoded)...)
+	layers, err := ReadTextLayers(context.Background(), testPrivateData(append(lines, "%AI5_EndLayer--")...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 2 {
+		t.Fatalf("got %d layers, expected 2", len(layers))
+	}
+	if layers[0].Content != "Hi" || layers[0].Frame != nil {
+		t.Errorf("layer 0 got %q with frame %v", layers[0].Content, layers[0].Frame)
+	}
+	if layers[1].Content != "a(b" || layers[1].Index != 1 || layers[1].Frame == nil || *layers[1].Frame != (TextFrame{120.5, 40}) {
+		t.Errorf("layer 1 got %+v", layers[1])
+	}
+}
+
+func TestUnescapeBinary(t *testing.T) {
+	raw := contents.LiteralString("a\r\nb\\\rc\\nd")
+	if got := raw.UnescapeBinary(); !bytes.Equal(got, []byte("a\r\nb\rc\nd")) {
+		t.Errorf("binary got %q", got)
+	}
+	if got := raw.Unescape(); !bytes.Equal(got, []byte("a\nbc\nd")) {
+		t.Errorf("text got %q", got)
+	}
+}
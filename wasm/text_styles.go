@@ -0,0 +1,346 @@
+package wasm
+
+import (
+	"github.com/opendesigndev/illustrator-parser-pdfcpu/wasm/contents"
+)
+
+// AI11TextDocument uses numbers instead of the key names known from Photoshop EngineData,
+// names below are the EngineData counterparts of keys observed in Illustrator files.
+const (
+	// root
+	tdKeyResources = "0"
+	tdKeyObjects   = "1"
+
+	// DocumentResources
+	tdKeyFontSet                 = "1"
+	tdKeyStyleSheetSet           = "5"
+	tdKeyParagraphSheetSet       = "6"
+	tdKeyTheNormalStyleSheet     = "8"
+	tdKeyTheNormalParagraphSheet = "9"
+
+	// every *Set is a dict holding an array of entries, each wrapping a resource
+	tdKeySetEntries = "0"
+	tdKeyResource   = "0"
+
+	// FontSet entry, StyleSheetSet and ParagraphSheetSet entries
+	tdKeyName = "0"
+	// Parent is an index into the same set, the normal sheet is the implicit root
+	tdKeyParent = "1"
+	// StyleSheetData of a style sheet, Properties of a paragraph sheet
+	tdKeyProperties = "5"
+	// DefaultStyleSheet within paragraph Properties, index into StyleSheetSet
+	tdKeyDefaultStyleSheet = "6"
+
+	// StyleSheetData
+	tdKeyFont        = "0"
+	tdKeyFontSize    = "1"
+	tdKeyAutoLeading = "4"
+	tdKeyLeading     = "5"
+	tdKeyTracking    = "8"
+	tdKeyFillColor   = "53"
+
+	// FillColor
+	tdKeyColorType   = "0"
+	tdKeyColorValues = "1"
+
+	// ParagraphSheet Properties
+	tdKeyJustification = "0"
+
+	// text object Model
+	tdKeyText         = "0"
+	tdKeyParagraphRun = "5"
+	tdKeyStyleRun     = "6"
+
+	// ParagraphRun and StyleRun hold RunArray, each run being a sheet applied to Length code units
+	tdKeyRunArray  = "0"
+	tdKeyRunSheet  = "0"
+	tdKeyRunLength = "1"
+)
+
+// TextColor is FillColor of a style sheet, Type 1 means Values are [alpha r g b]
+type TextColor struct {
+	Type   int       `json:"type"`
+	Values []float64 `json:"values"`
+}
+
+// CharacterStyle holds attributes of StyleSheetData, unset ones are nil
+type CharacterStyle struct {
+	Font        string     `json:"font,omitempty"`
+	FontSize    *float64   `json:"fontSize,omitempty"`
+	Tracking    *float64   `json:"tracking,omitempty"`
+	Leading     *float64   `json:"leading,omitempty"`
+	AutoLeading *bool      `json:"autoLeading,omitempty"`
+	FillColor   *TextColor `json:"fillColor,omitempty"`
+}
+
+// inherit fills attributes missing in s from parent
+func (s CharacterStyle) inherit(parent CharacterStyle) CharacterStyle {
+	if s.Font == "" {
+		s.Font = parent.Font
+	}
+	if s.FontSize == nil {
+		s.FontSize = parent.FontSize
+	}
+	if s.Tracking == nil {
+		s.Tracking = parent.Tracking
+	}
+	if s.Leading == nil {
+		s.Leading = parent.Leading
+	}
+	if s.AutoLeading == nil {
+		s.AutoLeading = parent.AutoLeading
+	}
+	if s.FillColor == nil {
+		s.FillColor = parent.FillColor
+	}
+	return s
+}
+
+type TextAlignment string
+
+const (
+	TextAlignmentLeft              TextAlignment = "Left"
+	TextAlignmentRight             TextAlignment = "Right"
+	TextAlignmentCenter            TextAlignment = "Center"
+	TextAlignmentJustifyLastLeft   TextAlignment = "JustifyLastLeft"
+	TextAlignmentJustifyLastRight  TextAlignment = "JustifyLastRight"
+	TextAlignmentJustifyLastCenter TextAlignment = "JustifyLastCenter"
+	TextAlignmentJustifyAll        TextAlignment = "JustifyAll"
+)
+
+// order of Justification values
+var textAlignments = []TextAlignment{
+	TextAlignmentLeft,
+	TextAlignmentRight,
+	TextAlignmentCenter,
+	TextAlignmentJustifyLastLeft,
+	TextAlignmentJustifyLastRight,
+	TextAlignmentJustifyLastCenter,
+	TextAlignmentJustifyAll,
+}
+
+// ParagraphStyle holds attributes of ParagraphSheet together with its default character style
+type ParagraphStyle struct {
+	Alignment TextAlignment  `json:"alignment,omitempty"`
+	Character CharacterStyle `json:"character"`
+}
+
+func (s ParagraphStyle) inherit(parent ParagraphStyle) ParagraphStyle {
+	if s.Alignment == "" {
+		s.Alignment = parent.Alignment
+	}
+	s.Character = s.Character.inherit(parent.Character)
+	return s
+}
+
+// StyleRun applies character style to Length UTF-16 code units of content starting at Start - same as String.prototype.substr
+type StyleRun struct {
+	Start  int            `json:"start"`
+	Length int            `json:"length"`
+	Name   string         `json:"name,omitempty"`
+	Style  CharacterStyle `json:"style"`
+}
+
+// ParagraphRun applies paragraph style to Length UTF-16 code units of content starting at Start
+type ParagraphRun struct {
+	Start  int            `json:"start"`
+	Length int            `json:"length"`
+	Name   string         `json:"name,omitempty"`
+	Style  ParagraphStyle `json:"style"`
+}
+
+// textStyles resolves style sheets of DocumentResources, each run is resolved against:
+// its own attributes -> referenced sheet -> parents of that sheet -> the normal sheet
+type textStyles struct {
+	fonts             []string
+	styleSheets       []contents.Dict
+	paragraphSheets   []contents.Dict
+	normalStyleSheet  int
+	normalParagraph   int
+	resolvedStyles    map[int]CharacterStyle
+	resolvedParagraph map[int]ParagraphStyle
+}
+
+func newTextStyles(textDocument contents.Dict) *textStyles {
+	resources := tdDict(textDocument, tdKeyResources)
+	ts := textStyles{
+		styleSheets:       tdSet(resources, tdKeyStyleSheetSet),
+		paragraphSheets:   tdSet(resources, tdKeyParagraphSheetSet),
+		normalStyleSheet:  tdInt(resources, tdKeyTheNormalStyleSheet, -1),
+		normalParagraph:   tdInt(resources, tdKeyTheNormalParagraphSheet, -1),
+		resolvedStyles:    map[int]CharacterStyle{},
+		resolvedParagraph: map[int]ParagraphStyle{},
+	}
+	for _, font := range tdSet(resources, tdKeyFontSet) {
+		ts.fonts = append(ts.fonts, tdString(font, tdKeyName))
+	}
+	return &ts
+}
+
+func (ts *textStyles) characterStyle(data contents.Dict) CharacterStyle {
+	var s CharacterStyle
+	if idx := tdInt(data, tdKeyFont, -1); idx >= 0 && idx < len(ts.fonts) {
+		s.Font = ts.fonts[idx]
+	}
+	s.FontSize = tdNumber(data, tdKeyFontSize)
+	s.Tracking = tdNumber(data, tdKeyTracking)
+	s.Leading = tdNumber(data, tdKeyLeading)
+	if val, ok := data[tdKeyAutoLeading].(contents.Boolean); ok {
+		autoLeading := bool(val)
+		s.AutoLeading = &autoLeading
+	}
+	if color := tdDict(data, tdKeyFillColor); color != nil {
+		s.FillColor = &TextColor{Type: tdInt(color, tdKeyColorType, 0)}
+		if values, ok := color[tdKeyColorValues].(contents.Array); ok {
+			for _, val := range values {
+				if num, ok := val.(contents.Number); ok {
+					s.FillColor.Values = append(s.FillColor.Values, float64(num))
+				}
+			}
+		}
+	}
+	return s
+}
+
+// styleSheet resolves StyleSheetSet entry with its ancestors, seen guards against cyclic Parent
+func (ts *textStyles) styleSheet(idx int, seen map[int]bool) CharacterStyle {
+	if style, ok := ts.resolvedStyles[idx]; ok {
+		return style
+	}
+	if idx < 0 || idx >= len(ts.styleSheets) || seen[idx] {
+		return CharacterStyle{}
+	}
+	seen[idx] = true
+	sheet := ts.styleSheets[idx]
+	style := ts.characterStyle(tdDict(sheet, tdKeyProperties))
+	parent := tdInt(sheet, tdKeyParent, ts.normalStyleSheet)
+	if idx != ts.normalStyleSheet {
+		style = style.inherit(ts.styleSheet(parent, seen))
+	}
+	ts.resolvedStyles[idx] = style
+	return style
+}
+
+func (ts *textStyles) paragraphStyle(props contents.Dict) ParagraphStyle {
+	var s ParagraphStyle
+	if idx := tdInt(props, tdKeyJustification, -1); idx >= 0 && idx < len(textAlignments) {
+		s.Alignment = textAlignments[idx]
+	}
+	if idx := tdInt(props, tdKeyDefaultStyleSheet, -1); idx >= 0 {
+		s.Character = ts.styleSheet(idx, map[int]bool{})
+	}
+	return s
+}
+
+func (ts *textStyles) paragraphSheet(idx int, seen map[int]bool) ParagraphStyle {
+	if style, ok := ts.resolvedParagraph[idx]; ok {
+		return style
+	}
+	if idx < 0 || idx >= len(ts.paragraphSheets) || seen[idx] {
+		return ParagraphStyle{}
+	}
+	seen[idx] = true
+	sheet := ts.paragraphSheets[idx]
+	style := ts.paragraphStyle(tdDict(sheet, tdKeyProperties))
+	parent := tdInt(sheet, tdKeyParent, ts.normalParagraph)
+	if idx != ts.normalParagraph {
+		style = style.inherit(ts.paragraphSheet(parent, seen))
+	}
+	ts.resolvedParagraph[idx] = style
+	return style
+}
+
+func (ts *textStyles) styleRuns(model contents.Dict) []StyleRun {
+	var runs []StyleRun
+	start := 0
+	for _, run := range tdRuns(model, tdKeyStyleRun) {
+		sheet := tdDict(run, tdKeyRunSheet)
+		parent := tdInt(sheet, tdKeyParent, ts.normalStyleSheet)
+		entry := StyleRun{
+			Start:  start,
+			Length: tdInt(run, tdKeyRunLength, 0),
+			Style:  ts.characterStyle(tdDict(sheet, tdKeyProperties)).inherit(ts.styleSheet(parent, map[int]bool{})),
+		}
+		if parent >= 0 && parent < len(ts.styleSheets) {
+			entry.Name = tdString(ts.styleSheets[parent], tdKeyName)
+		}
+		runs = append(runs, entry)
+		start += entry.Length
+	}
+	return runs
+}
+
+func (ts *textStyles) paragraphRuns(model contents.Dict) []ParagraphRun {
+	var runs []ParagraphRun
+	start := 0
+	for _, run := range tdRuns(model, tdKeyParagraphRun) {
+		sheet := tdDict(run, tdKeyRunSheet)
+		parent := tdInt(sheet, tdKeyParent, ts.normalParagraph)
+		entry := ParagraphRun{
+			Start:  start,
+			Length: tdInt(run, tdKeyRunLength, 0),
+			Style:  ts.paragraphStyle(tdDict(sheet, tdKeyProperties)).inherit(ts.paragraphSheet(parent, map[int]bool{})),
+		}
+		if parent >= 0 && parent < len(ts.paragraphSheets) {
+			entry.Name = tdString(ts.paragraphSheets[parent], tdKeyName)
+		}
+		runs = append(runs, entry)
+		start += entry.Length
+	}
+	return runs
+}
+
+func tdDict(dict contents.Dict, key string) contents.Dict {
+	val, _ := dict[key].(contents.Dict)
+	return val
+}
+
+func tdNumber(dict contents.Dict, key string) *float64 {
+	val, ok := dict[key].(contents.Number)
+	if !ok {
+		return nil
+	}
+	num := float64(val)
+	return &num
+}
+
+func tdInt(dict contents.Dict, key string, fallback int) int {
+	if val := tdNumber(dict, key); val != nil {
+		return int(*val)
+	}
+	return fallback
+}
+
+// tdString decodes UTF-16BE string, falling back to raw bytes if there's no BOM
+func tdString(dict contents.Dict, key string) string {
+	val, ok := dict[key].(contents.LiteralString)
+	if !ok {
+		return ""
+	}
+	raw := val.UnescapeBinary()
+	if str, err := decodeUTF16BE(raw); err == nil {
+		return str
+	}
+	return string(raw)
+}
+
+// tdSet returns resources held by a *Set, e.g. FontSet
+func tdSet(dict contents.Dict, key string) []contents.Dict {
+	entries, _ := tdDict(dict, key)[tdKeySetEntries].(contents.Array)
+	ret := make([]contents.Dict, 0, len(entries))
+	for _, entry := range entries {
+		entryDict, _ := entry.(contents.Dict)
+		ret = append(ret, tdDict(entryDict, tdKeyResource))
+	}
+	return ret
+}
+
+func tdRuns(model contents.Dict, key string) []contents.Dict {
+	runArray, _ := tdDict(model, key)[tdKeyRunArray].(contents.Array)
+	ret := make([]contents.Dict, 0, len(runArray))
+	for _, run := range runArray {
+		runDict, _ := run.(contents.Dict)
+		ret = append(ret, runDict)
+	}
+	return ret
+}
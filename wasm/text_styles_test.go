@@ -0,0 +1,52 @@
+package wasm
+
+import (
+	"context"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestReadTextLayersStyles(t *testing.T) {
+	decoded, err := ioutil.ReadFile("testdata/text-document.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, err := ReadTextLayers(context.Background(), testPrivateData(testTextDocumentLines(string(decoded))...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 1 || layers[0].Content != "Hello world" {
+		t.Fatalf("got %+v", layers)
+	}
+
+	number := func(val float64) *float64 { return &val }
+	autoLeading := true
+	normal := CharacterStyle{
+		Font:        "MyriadPro-Regular",
+		FontSize:    number(12),
+		Tracking:    number(0),
+		Leading:     number(14.4),
+		AutoLeading: &autoLeading,
+		FillColor:   &TextColor{Type: 1, Values: []float64{1, 0, 0, 0}},
+	}
+	large := normal
+	large.FontSize = number(18)
+	emphasis := normal
+	emphasis.Font = "MyriadPro-Bold"
+	emphasis.FillColor = &TextColor{Type: 1, Values: []float64{1, 1, 0, 0}}
+
+	paragraphs := []ParagraphRun{
+		{Start: 0, Length: 12, Name: "Centered", Style: ParagraphStyle{Alignment: TextAlignmentCenter, Character: normal}},
+	}
+	if !reflect.DeepEqual(layers[0].Paragraphs, paragraphs) {
+		t.Errorf("paragraphs got %+v, expected %+v", layers[0].Paragraphs, paragraphs)
+	}
+	styles := []StyleRun{
+		{Start: 0, Length: 6, Name: "Normal", Style: large},
+		{Start: 6, Length: 6, Name: "Emphasis", Style: emphasis},
+	}
+	if !reflect.DeepEqual(layers[0].Styles, styles) {
+		t.Errorf("styles got %+v, expected %+v", layers[0].Styles, styles)
+	}
+}
@@ -0,0 +1,207 @@
+package wasm
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// Compression of private data written by WritePrivateData
+type Compression int
+
+const (
+	// CompressionAuto keeps compression of the private data being replaced
+	CompressionAuto Compression = iota
+	CompressionNone
+	// CompressionZlib writes %AI12_CompressedData
+	CompressionZlib
+	// CompressionZstd writes %AI24_ZStandard_Data
+	CompressionZstd
+)
+
+// DefaultBlockSize is the size of AIPrivateData blocks written by Illustrator
+const DefaultBlockSize = 64 * 1024
+
+type WriteOptions struct {
+	Compression Compression
+	// BlockSize bounds AIPrivateData blocks, DefaultBlockSize if 0
+	BlockSize int
+	// ObjNr selects one of IllustratorFile.PrivateStreams, the first one if 0
+	ObjNr int
+}
+
+// WritePrivateData copies PDF from rs to w with private data replaced by data - the inverse of private data extraction.
+// data holds lines terminated by \r, same as PrivateData yields them - AIMetaData is kept, so if data starts with its
+// lines, those are skipped whatever their terminators (\r, \n or \r\n). Everything else is written by pdfcpu as read, objects unreachable from the catalog are dropped.
+func WritePrivateData(ctx context.Context, rs io.ReadSeeker, w io.Writer, data io.Reader, conf *Configuration, opts WriteOptions) error {
+	pdfCtx, err := readContext(ctx, rs, &conf.Configuration)
+	if err != nil {
+		return errors.WithMessage(err, "while opening read context")
+	}
+
+	streams, err := extractPrivateStreams(ctx, pdfCtx, newLimiter(conf.Limits), nil)
+	if err != nil {
+		return errors.WithMessage(err, "whilst looking for private data")
+	}
+	stream := &streams[0]
+	if opts.ObjNr != 0 {
+		stream = nil
+		for idx := range streams {
+			if streams[idx].ObjNr == opts.ObjNr {
+				stream = &streams[idx]
+			}
+		}
+		if stream == nil {
+			return errors.Errorf("private dict (obj#:%d) not found", opts.ObjNr)
+		}
+	}
+
+	content, err := io.ReadAll(&contextReader{ctx, data})
+	if err != nil {
+		return errors.Wrap(err, "while reading private data")
+	}
+	meta, _, err := pdfCtx.DereferenceStreamDict(stream.dict["AIMetaData"])
+	if err == nil && meta != nil && meta.Decode() == nil {
+		content = trimLines(content, meta.Content)
+	}
+
+	compression := opts.Compression
+	if compression == CompressionAuto {
+		compression = stream.compression()
+	}
+	header, payload, err := compress(content, compression)
+	if err != nil {
+		return errors.WithMessage(err, "while compressing private data")
+	}
+
+	blockSize := opts.BlockSize
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	for key := range stream.dict {
+		if privateDataKeyRe.MatchString(key) {
+			delete(stream.dict, key)
+		}
+	}
+	numBlock := 0
+	for len(payload) > 0 || numBlock == 0 {
+		n := blockSize
+		if numBlock == 0 && n < len(header) {
+			// the header is looked for at the start of a block, it must not be split
+			n = len(header)
+		}
+		if n > len(payload) {
+			n = len(payload)
+		}
+		numBlock++
+		ref, err := newBlock(pdfCtx.XRefTable, payload[:n])
+		if err != nil {
+			return errors.WithMessagef(err, "while adding block %d", numBlock)
+		}
+		stream.dict["AIPrivateData"+strconv.Itoa(numBlock)] = *ref
+		payload = payload[n:]
+	}
+	stream.dict["NumBlock"] = pdfcpu.Integer(numBlock)
+
+	if err := api.WriteContext(pdfCtx, &contextWriter{ctx, w}); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return errors.WithMessage(err, "while writing PDF")
+	}
+	return nil
+}
+
+// trimLines returns content without leading lines of prefix, or content as is if it does not start with them. Lines
+// are compared without their terminators, since data edited outside of Illustrator may have them normalised.
+func trimLines(content, prefix []byte) []byte {
+	rest := content
+	for len(prefix) > 0 {
+		var line, prefixLine []byte
+		prefixLine, prefix = splitLine(prefix)
+		line, rest = splitLine(rest)
+		if !bytes.Equal(line, prefixLine) {
+			return content
+		}
+	}
+	return rest
+}
+
+// splitLine returns the first line of data without its terminator and the rest of data
+func splitLine(data []byte) ([]byte, []byte) {
+	idx := bytes.IndexAny(data, "\r\n")
+	if idx < 0 {
+		return data, nil
+	}
+	if data[idx] == '\r' && idx+1 < len(data) && data[idx+1] == '\n' {
+		return data[:idx], data[idx+2:]
+	}
+	return data[:idx], data[idx+1:]
+}
+
+var privateDataKeyRe = regexp.MustCompile(`^AI(PDF)?PrivateData\d+$`)
+
+// compression returns compression of the first compressed segment, CompressionNone if there is none or it's not known
+func (ps *PrivateStream) compression() Compression {
+	for _, seg := range ps.dc.segments {
+		switch seg.header {
+		case zlibHeader:
+			return CompressionZlib
+		case zstdHeader:
+			return CompressionZstd
+		}
+	}
+	return CompressionNone
+}
+
+// compress returns content prefixed by header of given compression, together with the header
+func compress(content []byte, compression Compression) (string, []byte, error) {
+	var buf bytes.Buffer
+	var wc io.WriteCloser
+	var header string
+	var err error
+	switch compression {
+	case CompressionNone:
+		return "", content, nil
+	case CompressionZlib:
+		header = zlibHeader
+		buf.WriteString(header)
+		wc = zlib.NewWriter(&buf)
+	case CompressionZstd:
+		header = zstdHeader
+		buf.WriteString(header)
+		if wc, err = zstd.NewWriter(&buf); err != nil {
+			return "", nil, err
+		}
+	default:
+		return "", nil, errors.Errorf("unknown compression %d", compression)
+	}
+	if _, err := wc.Write(content); err != nil {
+		wc.Close()
+		return "", nil, err
+	}
+	if err := wc.Close(); err != nil {
+		return "", nil, err
+	}
+	return header, buf.Bytes(), nil
+}
+
+// newBlock adds unfiltered stream - blocks following the header are read raw
+func newBlock(xRefTable *pdfcpu.XRefTable, block []byte) (*pdfcpu.IndirectRef, error) {
+	length := int64(len(block))
+	sd := pdfcpu.StreamDict{
+		Dict:         pdfcpu.Dict{"Length": pdfcpu.Integer(length)},
+		StreamLength: &length,
+		Raw:          block,
+		Content:      block,
+	}
+	return xRefTable.IndRefForNewObject(sd)
+}
@@ -0,0 +1,66 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWritePrivateData(t *testing.T) {
+	original := testIllustratorPDF([]string{"%AI5_BeginLayer", "%AI5_EndLayer--"}, "0 0 100 100", "", "")
+	lines := []string{"%AI5_BeginLayer", "1 1 1 1 0 0 0 79 128 255 0 50 Lb", "(Layer 1) Ln", "%AI5_EndLayer--", "%%EOF"}
+	tests := []struct {
+		name        string
+		compression Compression
+		data        string
+	}{
+		{"none", CompressionNone, strings.Join(lines, "\r")},
+		{"zlib", CompressionZlib, strings.Join(lines, "\r")},
+		{"zstd", CompressionZstd, strings.Join(lines, "\r")},
+		{"kept compression", CompressionAuto, strings.Join(lines, "\r")},
+		{"metadata with \\r", CompressionZlib, "%!PS-Adobe-3.0\r" + strings.Join(lines, "\r")},
+		{"metadata with \\n", CompressionZstd, "%!PS-Adobe-3.0\n" + strings.Join(lines, "\r")},
+		{"metadata with \\r\\n", CompressionNone, "%!PS-Adobe-3.0\r\n" + strings.Join(lines, "\r")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			var out bytes.Buffer
+			opts := WriteOptions{Compression: tt.compression, BlockSize: 16}
+			err := WritePrivateData(ctx, bytes.NewReader(original), &out, strings.NewReader(tt.data), testConfiguration(), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := ParseContext(ctx, bytes.NewReader(out.Bytes()), testConfiguration())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.PrivateData.Close()
+
+			expected := tt.compression
+			if expected == CompressionAuto {
+				expected = CompressionNone
+			}
+			if len(f.PrivateStreams) != 1 || f.PrivateStreams[0].compression() != expected {
+				t.Fatalf("got %d private streams, expected one compressed by %d", len(f.PrivateStreams), expected)
+			}
+			pd, err := f.privateData(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer pd.Close()
+			var got []string
+			for pd.Scan() {
+				got = append(got, string(pd.Bytes()))
+			}
+			if err := pd.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if want := append([]string{"%!PS-Adobe-3.0"}, lines...); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q, expected %q", got, want)
+			}
+		})
+	}
+}
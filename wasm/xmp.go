@@ -0,0 +1,284 @@
+package wasm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pkg/errors"
+)
+
+// XMP holds properties of the catalog Metadata stream written by Illustrator
+type XMP struct {
+	Title       string `json:"title"`
+	CreatorTool string `json:"creatorTool"`
+	// StartupProfile is the document profile the file was created with, e.g. Print or Web
+	StartupProfile string `json:"startupProfile"`
+	// ColorMode is the mode of process swatches (RGB or CMYK), which Illustrator keeps in document colour mode
+	ColorMode    string           `json:"colorMode"`
+	NPages       int              `json:"nPages"`
+	Fonts        []XMPFont        `json:"fonts,omitempty"`
+	PlateNames   []string         `json:"plateNames,omitempty"`
+	SwatchGroups []XMPSwatchGroup `json:"swatchGroups,omitempty"`
+	// Thumbnails are previews of the first artboard, JPEG as a rule
+	Thumbnails []XMPThumbnail `json:"thumbnails,omitempty"`
+}
+
+// XMPFont is an item of xmpTPg:Fonts
+type XMPFont struct {
+	Name      string `json:"name"`
+	Family    string `json:"family"`
+	Face      string `json:"face"`
+	Type      string `json:"type"`
+	Version   string `json:"version"`
+	FileName  string `json:"fileName"`
+	Composite bool   `json:"composite"`
+}
+
+// XMPSwatchGroup is an item of xmpTPg:SwatchGroups, Type is 0 for the default group and 1 for colour groups
+type XMPSwatchGroup struct {
+	Name      string        `json:"name"`
+	Type      int           `json:"type"`
+	Colorants []XMPColorant `json:"colorants,omitempty"`
+}
+
+// XMPColorant is a swatch of XMPSwatchGroup
+type XMPColorant struct {
+	Name string `json:"name"`
+	// Mode is RGB, CMYK, LAB or GRAY, Values are components in that mode - 0-255 for RGB, 0-100 for the others
+	Mode   string    `json:"mode"`
+	Values []float64 `json:"values"`
+	// Type is PROCESS or SPOT
+	Type string  `json:"type"`
+	Tint float64 `json:"tint"`
+}
+
+// XMPThumbnail is an item of xmp:Thumbnails, Image holds the decoded base64 data with Ext taken from xmpGImg:format
+type XMPThumbnail struct {
+	Width  int   `json:"width"`
+	Height int   `json:"height"`
+	Image  Image `json:"image"`
+}
+
+const nsRDF = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// rdfStrings is an rdf:Alt, rdf:Bag or rdf:Seq of plain values, Go tags can only name parents without namespace
+type rdfStrings struct {
+	Alt []string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Alt>li"`
+	Bag []string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Bag>li"`
+	Seq []string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Seq>li"`
+}
+
+func (rs rdfStrings) items() []string {
+	return append(append(append([]string{}, rs.Alt...), rs.Bag...), rs.Seq...)
+}
+
+// xmpDescription is a single rdf:Description, simple properties can be written either as elements or attributes
+type xmpDescription struct {
+	Title              rdfStrings `xml:"http://purl.org/dc/elements/1.1/ title"`
+	CreatorTool        string     `xml:"http://ns.adobe.com/xap/1.0/ CreatorTool"`
+	CreatorToolAttr    string     `xml:"http://ns.adobe.com/xap/1.0/ CreatorTool,attr"`
+	StartupProfile     string     `xml:"http://ns.adobe.com/illustrator/1.0/ StartupProfile"`
+	StartupProfileAttr string     `xml:"http://ns.adobe.com/illustrator/1.0/ StartupProfile,attr"`
+	NPages             string     `xml:"http://ns.adobe.com/xap/1.0/t/pg/ NPages"`
+	NPagesAttr         string     `xml:"http://ns.adobe.com/xap/1.0/t/pg/ NPages,attr"`
+	Fonts              struct {
+		Items []struct {
+			Name      string `xml:"http://ns.adobe.com/xap/1.0/sType/Font# fontName"`
+			Family    string `xml:"http://ns.adobe.com/xap/1.0/sType/Font# fontFamily"`
+			Face      string `xml:"http://ns.adobe.com/xap/1.0/sType/Font# fontFace"`
+			Type      string `xml:"http://ns.adobe.com/xap/1.0/sType/Font# fontType"`
+			Version   string `xml:"http://ns.adobe.com/xap/1.0/sType/Font# versionString"`
+			FileName  string `xml:"http://ns.adobe.com/xap/1.0/sType/Font# fontFileName"`
+			Composite string `xml:"http://ns.adobe.com/xap/1.0/sType/Font# composite"`
+		} `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Bag>li"`
+	} `xml:"http://ns.adobe.com/xap/1.0/t/pg/ Fonts"`
+	PlateNames   rdfStrings `xml:"http://ns.adobe.com/xap/1.0/t/pg/ PlateNames"`
+	SwatchGroups struct {
+		Items []struct {
+			Name      string `xml:"http://ns.adobe.com/xap/1.0/g/ groupName"`
+			Type      int    `xml:"http://ns.adobe.com/xap/1.0/g/ groupType"`
+			Colorants struct {
+				Items []xmpColorant `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Seq>li"`
+			} `xml:"http://ns.adobe.com/xap/1.0/g/ Colorants"`
+		} `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Seq>li"`
+	} `xml:"http://ns.adobe.com/xap/1.0/t/pg/ SwatchGroups"`
+	Thumbnails struct {
+		Items []struct {
+			Width  int    `xml:"http://ns.adobe.com/xap/1.0/g/img/ width"`
+			Height int    `xml:"http://ns.adobe.com/xap/1.0/g/img/ height"`
+			Format string `xml:"http://ns.adobe.com/xap/1.0/g/img/ format"`
+			Image  string `xml:"http://ns.adobe.com/xap/1.0/g/img/ image"`
+		} `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Alt>li"`
+	} `xml:"http://ns.adobe.com/xap/1.0/ Thumbnails"`
+}
+
+type xmpColorant struct {
+	Name    string   `xml:"http://ns.adobe.com/xap/1.0/g/ swatchName"`
+	Mode    string   `xml:"http://ns.adobe.com/xap/1.0/g/ mode"`
+	Type    string   `xml:"http://ns.adobe.com/xap/1.0/g/ type"`
+	Tint    *float64 `xml:"http://ns.adobe.com/xap/1.0/g/ tint"`
+	Red     float64  `xml:"http://ns.adobe.com/xap/1.0/g/ red"`
+	Green   float64  `xml:"http://ns.adobe.com/xap/1.0/g/ green"`
+	Blue    float64  `xml:"http://ns.adobe.com/xap/1.0/g/ blue"`
+	Cyan    float64  `xml:"http://ns.adobe.com/xap/1.0/g/ cyan"`
+	Magenta float64  `xml:"http://ns.adobe.com/xap/1.0/g/ magenta"`
+	Yellow  float64  `xml:"http://ns.adobe.com/xap/1.0/g/ yellow"`
+	Black   float64  `xml:"http://ns.adobe.com/xap/1.0/g/ black"`
+	L       float64  `xml:"http://ns.adobe.com/xap/1.0/g/ L"`
+	A       float64  `xml:"http://ns.adobe.com/xap/1.0/g/ A"`
+	B       float64  `xml:"http://ns.adobe.com/xap/1.0/g/ B"`
+	Gray    float64  `xml:"http://ns.adobe.com/xap/1.0/g/ gray"`
+}
+
+func (c xmpColorant) values() []float64 {
+	switch strings.ToUpper(c.Mode) {
+	case "RGB":
+		return []float64{c.Red, c.Green, c.Blue}
+	case "CMYK":
+		return []float64{c.Cyan, c.Magenta, c.Yellow, c.Black}
+	case "LAB":
+		return []float64{c.L, c.A, c.B}
+	case "GRAY":
+		return []float64{c.Gray}
+	}
+	return nil
+}
+
+// ParseXMP reads properties out of XMP packet, rdf:Description elements are looked for at any depth
+func ParseXMP(data []byte) (*XMP, error) {
+	var xmp XMP
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "while parsing XMP")
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Space != nsRDF || start.Name.Local != "Description" {
+			continue
+		}
+		var desc xmpDescription
+		if err := dec.DecodeElement(&desc, &start); err != nil {
+			return nil, errors.Wrap(err, "while parsing rdf:Description")
+		}
+		if err := xmp.merge(&desc); err != nil {
+			return nil, err
+		}
+	}
+	for _, group := range xmp.SwatchGroups {
+		for _, colorant := range group.Colorants {
+			if xmp.ColorMode == "" && colorant.Type == "PROCESS" {
+				xmp.ColorMode = colorant.Mode
+			}
+		}
+	}
+	return &xmp, nil
+}
+
+// merge fills properties of desc which are not set yet
+func (xmp *XMP) merge(desc *xmpDescription) error {
+	if titles := desc.Title.items(); xmp.Title == "" && len(titles) > 0 {
+		xmp.Title = titles[0]
+	}
+	setString(&xmp.CreatorTool, desc.CreatorTool, desc.CreatorToolAttr)
+	setString(&xmp.StartupProfile, desc.StartupProfile, desc.StartupProfileAttr)
+	var nPages string
+	if setString(&nPages, desc.NPages, desc.NPagesAttr); nPages != "" && xmp.NPages == 0 {
+		n, err := strconv.Atoi(strings.TrimSpace(nPages))
+		if err != nil {
+			return errors.Wrap(err, "xmpTPg:NPages")
+		}
+		xmp.NPages = n
+	}
+	for _, font := range desc.Fonts.Items {
+		xmp.Fonts = append(xmp.Fonts, XMPFont{
+			Name:      font.Name,
+			Family:    font.Family,
+			Face:      font.Face,
+			Type:      font.Type,
+			Version:   font.Version,
+			FileName:  font.FileName,
+			Composite: strings.EqualFold(font.Composite, "True"),
+		})
+	}
+	xmp.PlateNames = append(xmp.PlateNames, desc.PlateNames.items()...)
+	for _, group := range desc.SwatchGroups.Items {
+		swatchGroup := XMPSwatchGroup{Name: group.Name, Type: group.Type}
+		for _, colorant := range group.Colorants.Items {
+			tint := 100.0
+			if colorant.Tint != nil {
+				tint = *colorant.Tint
+			}
+			swatchGroup.Colorants = append(swatchGroup.Colorants, XMPColorant{
+				Name:   colorant.Name,
+				Mode:   colorant.Mode,
+				Values: colorant.values(),
+				Type:   colorant.Type,
+				Tint:   tint,
+			})
+		}
+		xmp.SwatchGroups = append(xmp.SwatchGroups, swatchGroup)
+	}
+	for idx, thumbnail := range desc.Thumbnails.Items {
+		// base64 is wrapped by &#xA; entities
+		content, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(thumbnail.Image), ""))
+		if err != nil {
+			return errors.Wrapf(err, "thumbnail %d", idx)
+		}
+		ext := strings.ToLower(thumbnail.Format)
+		if ext == "jpeg" {
+			ext = "jpg"
+		}
+		xmp.Thumbnails = append(xmp.Thumbnails, XMPThumbnail{thumbnail.Width, thumbnail.Height, Image{ext, content}})
+	}
+	return nil
+}
+
+// setString sets dst to the first non-empty value, unless it's set already
+func setString(dst *string, values ...string) {
+	for _, value := range values {
+		if *dst == "" {
+			*dst = strings.TrimSpace(value)
+		}
+	}
+}
+
+// readXMP parses catalog Metadata stream, nil if there is none
+func readXMP(xRefTable *pdfcpu.XRefTable) (*XMP, int, error) {
+	root, err := xRefTable.Catalog()
+	if err != nil {
+		return nil, 0, err
+	}
+	ref, ok := root["Metadata"].(pdfcpu.IndirectRef)
+	if !ok {
+		return nil, 0, nil
+	}
+	objNr := ref.ObjectNumber.Value()
+	sd, _, err := xRefTable.DereferenceStreamDict(ref)
+	if err != nil || sd == nil {
+		return nil, objNr, err
+	}
+	if err := sd.Decode(); err != nil {
+		return nil, objNr, errors.Wrap(err, "while decoding Metadata")
+	}
+	xmp, err := ParseXMP(sd.Content)
+	return xmp, objNr, err
+}
+
+// extractXMP returns XMP of the file, problems are recorded in diagnostics - metadata is not worth failing the parse
+func extractXMP(xRefTable *pdfcpu.XRefTable, diagnostics *[]Diagnostic) *XMP {
+	xmp, objNr, err := readXMP(xRefTable)
+	if err != nil {
+		*diagnostics = append(*diagnostics, Diagnostic{ObjNr: objNr, Key: "Metadata", Message: err.Error()})
+		return nil
+	}
+	return xmp
+}
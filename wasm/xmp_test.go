@@ -0,0 +1,260 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testThumbnail stands for JPEG data of a thumbnail
+var testThumbnail = []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00\x01\x02\x01\x00H\x00H\x00\x00\xff\xd9")
+
+// testXMP returns XMP packet as written by Illustrator, trimmed down to properties which are read. Simple properties
+// of xmp and illustrator namespaces are written as attributes if attrs.
+func testXMP(attrs bool) string {
+	// Illustrator wraps base64 every 76 characters
+	encoded := base64.StdEncoding.EncodeToString(testThumbnail)
+	wrapped := encoded[:20] + "&#xA;" + encoded[20:]
+	simple := `
+            <xmp:CreatorTool>Adobe Illustrator 26.0 (Macintosh)</xmp:CreatorTool>
+            <illustrator:StartupProfile>Print</illustrator:StartupProfile>`
+	simpleAttrs := ""
+	if attrs {
+		simple = ""
+		simpleAttrs = `
+            xmp:CreatorTool="Adobe Illustrator 26.0 (Macintosh)"
+            illustrator:StartupProfile="Print"`
+	}
+	return `<?xpacket begin="` + "\ufeff" + `" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/" x:xmptk="Adobe XMP Core 7.1-c000 79.9ccc4de93, 2022/03/14-14:07:22        ">
+   <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+      <rdf:Description rdf:about=""
+            xmlns:dc="http://purl.org/dc/elements/1.1/">
+         <dc:format>application/pdf</dc:format>
+         <dc:title>
+            <rdf:Alt>
+               <rdf:li xml:lang="x-default">Poster</rdf:li>
+            </rdf:Alt>
+         </dc:title>
+      </rdf:Description>
+      <rdf:Description rdf:about=""
+            xmlns:xmp="http://ns.adobe.com/xap/1.0/"
+            xmlns:xmpGImg="http://ns.adobe.com/xap/1.0/g/img/"
+            xmlns:illustrator="http://ns.adobe.com/illustrator/1.0/"` + simpleAttrs + `>` + simple + `
+         <xmp:CreateDate>2022-05-02T10:00:00+02:00</xmp:CreateDate>
+         <xmp:Thumbnails>
+            <rdf:Alt>
+               <rdf:li rdf:parseType="Resource">
+                  <xmpGImg:width>256</xmpGImg:width>
+                  <xmpGImg:height>180</xmpGImg:height>
+                  <xmpGImg:format>JPEG</xmpGImg:format>
+                  <xmpGImg:image>` + wrapped + `</xmpGImg:image>
+               </rdf:li>
+            </rdf:Alt>
+         </xmp:Thumbnails>
+      </rdf:Description>
+      <rdf:Description rdf:about=""
+            xmlns:xmpTPg="http://ns.adobe.com/xap/1.0/t/pg/"
+            xmlns:stDim="http://ns.adobe.com/xap/1.0/sType/Dimensions#"
+            xmlns:stFnt="http://ns.adobe.com/xap/1.0/sType/Font#"
+            xmlns:xmpG="http://ns.adobe.com/xap/1.0/g/">
+         <xmpTPg:NPages>1</xmpTPg:NPages>
+         <xmpTPg:HasVisibleTransparency>False</xmpTPg:HasVisibleTransparency>
+         <xmpTPg:MaxPageSize rdf:parseType="Resource">
+            <stDim:w>595.275591</stDim:w>
+            <stDim:h>841.889764</stDim:h>
+            <stDim:unit>Points</stDim:unit>
+         </xmpTPg:MaxPageSize>
+         <xmpTPg:Fonts>
+            <rdf:Bag>
+               <rdf:li rdf:parseType="Resource">
+                  <stFnt:fontName>MyriadPro-Regular</stFnt:fontName>
+                  <stFnt:fontFamily>Myriad Pro</stFnt:fontFamily>
+                  <stFnt:fontFace>Regular</stFnt:fontFace>
+                  <stFnt:fontType>Open Type</stFnt:fontType>
+                  <stFnt:versionString>Version 2.106;PS 2.000;hotconv 1.0.70;makeotf.lib2.5.58329</stFnt:versionString>
+                  <stFnt:composite>False</stFnt:composite>
+                  <stFnt:fontFileName>MyriadPro-Regular.otf</stFnt:fontFileName>
+               </rdf:li>
+            </rdf:Bag>
+         </xmpTPg:Fonts>
+         <xmpTPg:PlateNames>
+            <rdf:Seq>
+               <rdf:li>Cyan</rdf:li>
+               <rdf:li>Magenta</rdf:li>
+               <rdf:li>Yellow</rdf:li>
+               <rdf:li>Black</rdf:li>
+            </rdf:Seq>
+         </xmpTPg:PlateNames>
+         <xmpTPg:SwatchGroups>
+            <rdf:Seq>
+               <rdf:li rdf:parseType="Resource">
+                  <xmpG:groupName>Default Swatch Group</xmpG:groupName>
+                  <xmpG:groupType>0</xmpG:groupType>
+                  <xmpG:Colorants>
+                     <rdf:Seq>
+                        <rdf:li rdf:parseType="Resource">
+                           <xmpG:swatchName>White</xmpG:swatchName>
+                           <xmpG:mode>CMYK</xmpG:mode>
+                           <xmpG:type>PROCESS</xmpG:type>
+                           <xmpG:cyan>0.000000</xmpG:cyan>
+                           <xmpG:magenta>0.000000</xmpG:magenta>
+                           <xmpG:yellow>0.000000</xmpG:yellow>
+                           <xmpG:black>0.000000</xmpG:black>
+                        </rdf:li>
+                        <rdf:li rdf:parseType="Resource">
+                           <xmpG:swatchName>PANTONE 185 C</xmpG:swatchName>
+                           <xmpG:type>SPOT</xmpG:type>
+                           <xmpG:tint>100.000000</xmpG:tint>
+                           <xmpG:mode>RGB</xmpG:mode>
+                           <xmpG:red>228</xmpG:red>
+                           <xmpG:green>0</xmpG:green>
+                           <xmpG:blue>43</xmpG:blue>
+                        </rdf:li>
+                     </rdf:Seq>
+                  </xmpG:Colorants>
+               </rdf:li>
+               <rdf:li rdf:parseType="Resource">
+                  <xmpG:groupName>Grays</xmpG:groupName>
+                  <xmpG:groupType>1</xmpG:groupType>
+                  <xmpG:Colorants>
+                     <rdf:Seq>
+                        <rdf:li rdf:parseType="Resource">
+                           <xmpG:swatchName>K=50</xmpG:swatchName>
+                           <xmpG:mode>GRAY</xmpG:mode>
+                           <xmpG:type>PROCESS</xmpG:type>
+                           <xmpG:tint>50.000000</xmpG:tint>
+                           <xmpG:gray>50</xmpG:gray>
+                        </rdf:li>
+                     </rdf:Seq>
+                  </xmpG:Colorants>
+               </rdf:li>
+            </rdf:Seq>
+         </xmpTPg:SwatchGroups>
+      </rdf:Description>
+   </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`
+}
+
+var testParsedXMP = &XMP{
+	Title:          "Poster",
+	CreatorTool:    "Adobe Illustrator 26.0 (Macintosh)",
+	StartupProfile: "Print",
+	ColorMode:      "CMYK",
+	NPages:         1,
+	Fonts: []XMPFont{{
+		Name:     "MyriadPro-Regular",
+		Family:   "Myriad Pro",
+		Face:     "Regular",
+		Type:     "Open Type",
+		Version:  "Version 2.106;PS 2.000;hotconv 1.0.70;makeotf.lib2.5.58329",
+		FileName: "MyriadPro-Regular.otf",
+	}},
+	PlateNames: []string{"Cyan", "Magenta", "Yellow", "Black"},
+	SwatchGroups: []XMPSwatchGroup{
+		{Name: "Default Swatch Group", Type: 0, Colorants: []XMPColorant{
+			{Name: "White", Mode: "CMYK", Values: []float64{0, 0, 0, 0}, Type: "PROCESS", Tint: 100},
+			{Name: "PANTONE 185 C", Mode: "RGB", Values: []float64{228, 0, 43}, Type: "SPOT", Tint: 100},
+		}},
+		{Name: "Grays", Type: 1, Colorants: []XMPColorant{
+			{Name: "K=50", Mode: "GRAY", Values: []float64{50}, Type: "PROCESS", Tint: 50},
+		}},
+	},
+	Thumbnails: []XMPThumbnail{{Width: 256, Height: 180, Image: Image{Ext: "jpg", Content: testThumbnail}}},
+}
+
+func TestParseXMP(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		attrs bool
+	}{{"elements", false}, {"attributes", true}} {
+		t.Run(tt.name, func(t *testing.T) {
+			xmp, err := ParseXMP([]byte(testXMP(tt.attrs)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(xmp, testParsedXMP) {
+				t.Errorf("got %+v, want %+v", xmp, testParsedXMP)
+			}
+		})
+	}
+}
+
+func TestParseXMPMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		xmp   string
+		error string
+	}{
+		{"xml", strings.Replace(testXMP(false), "</rdf:RDF>", "", 1), "while parsing"},
+		{"base64", strings.Replace(testXMP(false), "<xmpGImg:image>", "<xmpGImg:image>*", 1), "thumbnail 0"},
+		{"pages", strings.Replace(testXMP(false), "<xmpTPg:NPages>1", "<xmpTPg:NPages>one", 1), "xmpTPg:NPages"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseXMP([]byte(tt.xmp)); err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("got %v, want error of %s", err, tt.error)
+			}
+		})
+	}
+}
+
+func TestXMPJSON(t *testing.T) {
+	data, err := json.Marshal(testParsedXMP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{`"creatorTool":`, `"startupProfile":`, `"nPages":1`, `"fileName":`, `"swatchGroups":`, `"colorants":`, `"thumbnails":`, `"width":256`} {
+		if !bytes.Contains(data, []byte(key)) {
+			t.Errorf("%s missing in %s", key, data)
+		}
+	}
+}
+
+// testXMPPDF returns testIllustratorPDF with catalog Metadata stream 9 holding xmp
+func testXMPPDF(xmp string) []byte {
+	objects := testIllustratorObjects(testIndexedLines, "0 0 100 100", "", "", testStream("/Type /Metadata /Subtype /XML", xmp))
+	objects[0] = strings.Replace(objects[0], " >>", " /Metadata 9 0 R >>", 1)
+	return testPDF(objects...)
+}
+
+func TestExtractXMP(t *testing.T) {
+	ctx := context.Background()
+	f, err := ParseContext(ctx, bytes.NewReader(testXMPPDF(testXMP(false))), testConfiguration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.PrivateData.Close()
+	if !reflect.DeepEqual(f.XMP, testParsedXMP) {
+		t.Errorf("got %+v, want %+v", f.XMP, testParsedXMP)
+	}
+	for _, d := range f.Diagnostics {
+		if d.Key == "Metadata" {
+			t.Errorf("unexpected %+v", d)
+		}
+	}
+
+	// malformed metadata doesn't fail parsing, it's left out
+	malformed := strings.Replace(testXMP(false), "</rdf:RDF>", "", 1)
+	if f, err = ParseContext(ctx, bytes.NewReader(testXMPPDF(malformed)), testConfiguration()); err != nil {
+		t.Fatal(err)
+	}
+	f.PrivateData.Close()
+	if f.XMP != nil {
+		t.Errorf("got %+v of malformed metadata", f.XMP)
+	}
+	var metadata []Diagnostic
+	for _, d := range f.Diagnostics {
+		if d.Key == "Metadata" {
+			metadata = append(metadata, d)
+		}
+	}
+	if len(metadata) != 1 || metadata[0].ObjNr != 9 || !strings.Contains(metadata[0].Message, "while parsing") {
+		t.Errorf("got diagnostics %+v, want malformed Metadata of obj 9", f.Diagnostics)
+	}
+}